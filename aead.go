@@ -0,0 +1,312 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// error defined
+var (
+	ErrInvalidNonceSize = errors.New("nonce length must equal nonce size")
+	ErrAuthFailed       = errors.New("message authentication failed")
+	ErrGCMNonceTagSize  = errors.New("gcm nonce size and tag size can not be both non-standard")
+)
+
+// gcm standard nonce and tag size
+const (
+	gcmStandardNonceSize = 12
+	gcmStandardTagSize   = 16
+)
+
+// WithGCMNonceSize option gcm nonce size, default 12 bytes, it must be positive.
+// only use a non-standard nonce size if required for compatibility, such as 16 bytes.
+func WithGCMNonceSize(size int) Option {
+	return func(c *config) {
+		c.gcmNonceSize = size
+	}
+}
+
+// WithGCMTagSize option gcm tag size, default 16 bytes, it must be between 12 and 16 bytes.
+// NOTE: a truncated tag reduces the authentication strength, a forger needs about 2^(8*tagSize)
+// attempts to get a message accepted, and for gcm the security degrades further with the
+// message length, so only use it for bandwidth-constrained protocols which mandate it.
+// it can not be used together with a non-standard nonce size.
+func WithGCMTagSize(size int) Option {
+	return func(c *config) {
+		c.gcmTagSize = size
+	}
+}
+
+// WithAEAD option the aead factory of NewAEADCrypt, default cipher.NewGCM,
+// it plugs any cipher.AEAD implementation built on a cipher.Block, such as a third-party one.
+func WithAEAD(newAEAD func(block cipher.Block) (cipher.AEAD, error)) Option {
+	return func(c *config) {
+		c.newAEAD = newAEAD
+	}
+}
+
+// AEADCrypt authenticated encryption with associated data interface,
+// it mirrors cipher.AEAD, so the code uses BlockCrypt can be migrated to the authenticated modes
+// with minimal churn, and it can be used anywhere a cipher.AEAD is required.
+//	Seal encrypts and authenticates plain text, authenticates the additional data,
+//	appends the cipher text with tag to dst, not contains nonce, it panics if the nonce length is wrong.
+//	Open decrypts and authenticates cipher text, authenticates the additional data,
+//	appends the plain text to dst, return ErrInvalidNonceSize if the nonce length is wrong,
+//	ErrCipherTextTooShort if the cipher text is shorter than Overhead(),
+//	ErrAuthFailed if the tag doesn't verify.
+// empty plain text is supported, it is sealed to the tag only, and opened to an empty slice.
+type AEADCrypt interface {
+	cipher.AEAD
+	// SealRandom generate a fresh random nonce from random source(see WithRand) and seal the plain text,
+	// return nonce + cipher text with tag appended, it avoids reusing nonce accidentally.
+	SealRandom(plainText, additionalData []byte) ([]byte, error)
+	// OpenRandom split the nonce off the blob which sealed by SealRandom, then open it.
+	OpenRandom(blob, additionalData []byte) ([]byte, error)
+	// SplitIV split the blob into the prepended nonce of NonceSize() bytes and the rest,
+	// return ErrCipherTextTooShort if the blob is shorter than NonceSize().
+	// both share the blob's storage, no copy.
+	SplitIV(blob []byte) (nonce, rest []byte, err error)
+	// SealSequence take the next nonce from the sequence and seal the plain text,
+	// return nonce + cipher text with tag appended, the same layout as SealRandom, so it can be
+	// opened by OpenRandom. it guarantees the nonce unique, return ErrNonceExhausted if the sequence exhausted.
+	SealSequence(seq *NonceSequence, plainText, additionalData []byte) ([]byte, error)
+	// SealDetached seal the plain text, return the cipher text and the tag separately,
+	// for the wire formats which carry the tag in a separate field, it panics if the nonce length is wrong.
+	SealDetached(nonce, plainText, additionalData []byte) (cipherText, tag []byte)
+	// OpenDetached open the cipher text with the separate tag, return ErrAuthFailed if the tag doesn't verify.
+	OpenDetached(nonce, cipherText, tag, additionalData []byte) ([]byte, error)
+	// OpenInPlace open the cipher text into its own storage, no allocation, the plain text returned
+	// is a sub slice of cipher text, it starts at Overhead() for the aead put the tag before the cipher text,
+	// such as siv, otherwise at 0. the cipher text is always clobbered, even if return error,
+	// so keep a copy if it is still needed.
+	// NOTE: it is the only safe aliasing, Open's dst must never overlap the cipher text
+	// except exactly cipherText[:0] for the aead put the tag after the cipher text.
+	OpenInPlace(nonce, cipherText, additionalData []byte) ([]byte, error)
+}
+
+// NewAEAD new gcm aead with newCipher, key and custom option
+// newCipher support follow or implement func(key []byte) (cipher.Block, error) with 128-bit block size:
+// 		aes
+// 		twofish
+// option support:
+//      WithGCMNonceSize
+//      WithGCMTagSize
+//      WithRand
+func NewAEAD(key []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (AEADCrypt, error) {
+	c := newConfig(opts...)
+	if c.gcmNonceSize <= 0 {
+		return nil, ErrInvalidNonceSize
+	}
+	block, err := newBlock(newCipher, key)
+	if err != nil {
+		return nil, err
+	}
+	var aead cipher.AEAD
+	switch {
+	case c.gcmNonceSize != gcmStandardNonceSize && c.gcmTagSize != gcmStandardTagSize:
+		return nil, ErrGCMNonceTagSize
+	case c.gcmNonceSize != gcmStandardNonceSize:
+		aead, err = cipher.NewGCMWithNonceSize(block, c.gcmNonceSize)
+	case c.gcmTagSize != gcmStandardTagSize:
+		aead, err = cipher.NewGCMWithTagSize(block, c.gcmTagSize)
+	default:
+		aead, err = cipher.NewGCM(block)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &aeadCrypt{aead, c.rand}, nil
+}
+
+// NewAEADCrypt new aead with newCipher, key, nonceSize and custom option,
+// the aead is created by the factory set by WithAEAD on the block, default cipher.NewGCM.
+// return ErrInvalidNonceSize if the aead's nonce size not equal nonceSize.
+// option support:
+//      WithAEAD
+//      WithRand
+func NewAEADCrypt(key []byte, nonceSize int, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (AEADCrypt, error) {
+	c := newConfig(opts...)
+	block, err := newBlock(newCipher, key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := c.newAEAD(block)
+	if err != nil {
+		return nil, err
+	}
+	if aead.NonceSize() != nonceSize {
+		return nil, ErrInvalidNonceSize
+	}
+	return &aeadCrypt{aead, c.rand}, nil
+}
+
+// tagPrefixer the aead which put the tag before the cipher text implement it, such as siv.
+type tagPrefixer interface {
+	tagPrefix() bool
+}
+
+// sizeLimiter the aead which limit the plain text size implement it, such as ccm.
+type sizeLimiter interface {
+	maxPlainTextSize() uint64
+}
+
+type aeadCrypt struct {
+	aead cipher.AEAD
+	rand io.Reader
+}
+
+func (sf *aeadCrypt) NonceSize() int {
+	return sf.aead.NonceSize()
+}
+
+func (sf *aeadCrypt) Overhead() int {
+	return sf.aead.Overhead()
+}
+
+// Seal seal
+func (sf *aeadCrypt) Seal(dst, nonce, plainText, additionalData []byte) []byte {
+	return sf.aead.Seal(dst, nonce, plainText, additionalData)
+}
+
+// Open open
+func (sf *aeadCrypt) Open(dst, nonce, cipherText, additionalData []byte) ([]byte, error) {
+	if len(nonce) != sf.aead.NonceSize() {
+		return nil, ErrInvalidNonceSize
+	}
+	if len(cipherText) < sf.aead.Overhead() {
+		return nil, ErrCipherTextTooShort
+	}
+	plainText, err := sf.aead.Open(dst, nonce, cipherText, additionalData)
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	if plainText == nil {
+		// empty plain text round-trips to empty, not nil, the same as BlockCrypt.
+		plainText = []byte{}
+	}
+	return plainText, nil
+}
+
+// SealRandom seal with random nonce
+func (sf *aeadCrypt) SealRandom(plainText, additionalData []byte) ([]byte, error) {
+	if err := sf.checkSize(plainText); err != nil {
+		return nil, err
+	}
+	nonceSize := sf.aead.NonceSize()
+	nonce := make([]byte, nonceSize, nonceSize+len(plainText)+sf.aead.Overhead())
+	if _, err := io.ReadFull(sf.rand, nonce); err != nil {
+		return nil, err
+	}
+	return sf.aead.Seal(nonce, nonce, plainText, additionalData), nil
+}
+
+// SealSequence seal with the nonce sequence
+func (sf *aeadCrypt) SealSequence(seq *NonceSequence, plainText, additionalData []byte) ([]byte, error) {
+	nonceSize := sf.aead.NonceSize()
+	if seq.NonceSize() != nonceSize {
+		return nil, ErrInvalidNonceSize
+	}
+	if err := sf.checkSize(plainText); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize, nonceSize+len(plainText)+sf.aead.Overhead())
+	if err := seq.next(nonce); err != nil {
+		return nil, err
+	}
+	return sf.aead.Seal(nonce, nonce, plainText, additionalData), nil
+}
+
+// SealDetached seal with the detached tag
+func (sf *aeadCrypt) SealDetached(nonce, plainText, additionalData []byte) (cipherText, tag []byte) {
+	sealed := sf.aead.Seal(nil, nonce, plainText, additionalData)
+	if sf.tagPrefix() {
+		tag, cipherText = sealed[:sf.aead.Overhead()], sealed[sf.aead.Overhead():]
+	} else {
+		cipherText, tag = sealed[:len(plainText)], sealed[len(plainText):]
+	}
+	return cipherText[:len(cipherText):len(cipherText)], tag[:len(tag):len(tag)]
+}
+
+// OpenDetached open with the detached tag
+func (sf *aeadCrypt) OpenDetached(nonce, cipherText, tag, additionalData []byte) ([]byte, error) {
+	if len(tag) != sf.aead.Overhead() {
+		return nil, ErrAuthFailed
+	}
+	sealed := make([]byte, 0, len(cipherText)+len(tag))
+	if sf.tagPrefix() {
+		sealed = append(append(sealed, tag...), cipherText...)
+	} else {
+		sealed = append(append(sealed, cipherText...), tag...)
+	}
+	return sf.Open(nil, nonce, sealed, additionalData)
+}
+
+// OpenInPlace open into the cipher text's storage
+func (sf *aeadCrypt) OpenInPlace(nonce, cipherText, additionalData []byte) ([]byte, error) {
+	overhead := sf.aead.Overhead()
+	if len(cipherText) < overhead {
+		return nil, ErrCipherTextTooShort
+	}
+	dst := cipherText[:0]
+	if sf.tagPrefix() {
+		// the plain text overlap the cipher text exactly, the tag before it is not overwritten
+		dst = cipherText[overhead:overhead]
+	}
+	return sf.Open(dst, nonce, cipherText, additionalData)
+}
+
+func (sf *aeadCrypt) tagPrefix() bool {
+	p, ok := sf.aead.(tagPrefixer)
+	return ok && p.tagPrefix()
+}
+
+// OpenRandom open with the prepended nonce
+func (sf *aeadCrypt) OpenRandom(blob, additionalData []byte) ([]byte, error) {
+	nonce, cipherText, err := sf.SplitIV(blob)
+	if err != nil {
+		return nil, err
+	}
+	return sf.Open(nil, nonce, cipherText, additionalData)
+}
+
+// SplitIV split the prepended nonce
+func (sf *aeadCrypt) SplitIV(blob []byte) ([]byte, []byte, error) {
+	nonceSize := sf.aead.NonceSize()
+	if len(blob) < nonceSize {
+		return nil, nil, ErrCipherTextTooShort
+	}
+	return blob[:nonceSize:nonceSize], blob[nonceSize:], nil
+}
+
+func (sf *aeadCrypt) checkSize(plainText []byte) error {
+	if l, ok := sf.aead.(sizeLimiter); ok && uint64(len(plainText)) > l.maxPlainTextSize() {
+		return ErrPlainTextTooLarge
+	}
+	return nil
+}
+
+// BuildAAD build the additional data from the parts, such as the record's id and type,
+// each part is prefixed with its 8 bytes big endian length, so the encoding is unambiguous,
+// ["ab", "c"] and ["a", "bc"] build different additional data, which plain concatenation doesn't,
+// it prevents swapping the bytes between the fields of untrusted length.
+//	aad := BuildAAD([]byte(record.ID), []byte(record.Type))
+//	blob, err := ad.SealRandom(plainText, aad)
+func BuildAAD(parts ...[]byte) []byte {
+	size := 0
+	for _, part := range parts {
+		size += 8 + len(part)
+	}
+	aad := make([]byte, 0, size)
+	for _, part := range parts {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		aad = append(append(aad, length[:]...), part...)
+	}
+	return aad
+}
@@ -0,0 +1,368 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAEADCipher(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+	nonce := []byte("unique_nonce")
+	additionalData := []byte("additional data")
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("gcm", func(t *testing.T) {
+		for _, keySize := range aesKeySizes {
+			ad, err := NewAEAD(key[:keySize], aes.NewCipher)
+			require.NoError(t, err)
+
+			assert.Equal(t, 12, ad.NonceSize())
+			assert.Equal(t, 16, ad.Overhead())
+
+			cipherText := ad.Seal(nil, nonce, plainText, additionalData)
+			assert.Equal(t, len(plainText)+ad.Overhead(), len(cipherText))
+
+			want, err := ad.Open(nil, nonce, cipherText, additionalData)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, want)
+		}
+	})
+
+	t.Run("dst append like cipher.AEAD", func(t *testing.T) {
+		ad, err := NewAEAD(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+		var _ cipher.AEAD = ad
+
+		prefix := []byte("prefix")
+		cipherText := ad.Seal(append([]byte{}, prefix...), nonce, plainText, additionalData)
+		assert.Equal(t, prefix, cipherText[:len(prefix)])
+		assert.Equal(t, ad.Seal(nil, nonce, plainText, additionalData), cipherText[len(prefix):])
+
+		got, err := ad.Open(append([]byte{}, prefix...), nonce, cipherText[len(prefix):], additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, append(append([]byte{}, prefix...), plainText...), got)
+	})
+
+	t.Run("gcm nonce size", func(t *testing.T) {
+		nonce := []byte("16_bytes_nonce__")
+		ad, err := NewAEAD(key[:16], aes.NewCipher, WithGCMNonceSize(16))
+		require.NoError(t, err)
+		assert.Equal(t, 16, ad.NonceSize())
+
+		cipherText := ad.Seal(nil, nonce, plainText, additionalData)
+		want, err := ad.Open(nil, nonce, cipherText, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, want)
+
+		require.Panics(t, func() { ad.Seal(nil, nonce[:12], plainText, additionalData) })
+
+		_, err = NewAEAD(key[:16], aes.NewCipher, WithGCMNonceSize(0))
+		require.Equal(t, ErrInvalidNonceSize, err)
+		_, err = NewAEAD(key[:16], aes.NewCipher, WithGCMNonceSize(-1))
+		require.Equal(t, ErrInvalidNonceSize, err)
+	})
+
+	t.Run("gcm tag size", func(t *testing.T) {
+		ad, err := NewAEAD(key[:16], aes.NewCipher, WithGCMTagSize(12))
+		require.NoError(t, err)
+		assert.Equal(t, 12, ad.Overhead())
+
+		cipherText := ad.Seal(nil, nonce, plainText, additionalData)
+		assert.Equal(t, len(plainText)+12, len(cipherText))
+		want, err := ad.Open(nil, nonce, cipherText, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, want)
+
+		_, err = ad.Open(nil, nonce, cipherText[:11], additionalData)
+		require.Equal(t, ErrCipherTextTooShort, err)
+
+		_, err = NewAEAD(key[:16], aes.NewCipher, WithGCMTagSize(8))
+		require.Error(t, err)
+		_, err = NewAEAD(key[:16], aes.NewCipher, WithGCMTagSize(12), WithGCMNonceSize(16))
+		require.Equal(t, ErrGCMNonceTagSize, err)
+	})
+
+	t.Run("random nonce", func(t *testing.T) {
+		ad, err := NewAEAD(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+
+		blob1, err := ad.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		blob2, err := ad.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		assert.NotEqual(t, blob1[:ad.NonceSize()], blob2[:ad.NonceSize()])
+		assert.Equal(t, ad.NonceSize()+len(plainText)+ad.Overhead(), len(blob1))
+
+		for _, blob := range [][]byte{blob1, blob2} {
+			got, err := ad.OpenRandom(blob, additionalData)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		_, err = ad.OpenRandom(blob1, nil)
+		require.Equal(t, ErrAuthFailed, err)
+		_, err = ad.OpenRandom(blob1[:ad.NonceSize()-1], additionalData)
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+
+	t.Run("split iv", func(t *testing.T) {
+		ad, err := NewAEAD(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+
+		blob, err := ad.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		gotNonce, cipherText, err := ad.SplitIV(blob)
+		require.NoError(t, err)
+		assert.Equal(t, blob[:ad.NonceSize()], gotNonce)
+		assert.Equal(t, ad.NonceSize(), cap(gotNonce))
+		got, err := ad.Open(nil, gotNonce, cipherText, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, _, err = ad.SplitIV(blob[:ad.NonceSize()-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+
+	t.Run("random nonce with rand", func(t *testing.T) {
+		ad, err := NewAEAD(key[:16], aes.NewCipher, WithRand(bytes.NewReader(nonce)))
+		require.NoError(t, err)
+
+		blob, err := ad.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		want := ad.Seal(nil, nonce, plainText, additionalData)
+		assert.Equal(t, append(append([]byte{}, nonce...), want...), blob)
+
+		// random source exhausted
+		_, err = ad.SealRandom(plainText, additionalData)
+		require.Error(t, err)
+	})
+
+	t.Run("empty plain text", func(t *testing.T) {
+		gcm, err := NewAEAD(key[:], aes.NewCipher)
+		require.NoError(t, err)
+		siv, err := NewSIV(key[:])
+		require.NoError(t, err)
+		chacha, err := NewChaCha20Poly1305(key[:])
+		require.NoError(t, err)
+		ccm, err := NewCCM(key[:16], 12, 16, aes.NewCipher)
+		require.NoError(t, err)
+		eax, err := NewEAX(key[:16], 12, aes.NewCipher)
+		require.NoError(t, err)
+
+		for name, ad := range map[string]AEADCrypt{
+			"gcm":    gcm,
+			"siv":    siv,
+			"chacha": chacha,
+			"ccm":    ccm,
+			"eax":    eax,
+		} {
+			blob, err := ad.SealRandom(nil, additionalData)
+			require.NoError(t, err, name)
+			assert.Len(t, blob, ad.NonceSize()+ad.Overhead(), name)
+			got, err := ad.OpenRandom(blob, additionalData)
+			require.NoError(t, err, name)
+			assert.NotNil(t, got, name)
+			assert.Empty(t, got, name)
+		}
+	})
+
+	t.Run("detached tag", func(t *testing.T) {
+		gcm, err := NewAEAD(key[:], aes.NewCipher)
+		require.NoError(t, err)
+		siv, err := NewSIV(key[:])
+		require.NoError(t, err)
+
+		for name, ad := range map[string]AEADCrypt{"gcm": gcm, "siv": siv} {
+			nonce := make([]byte, ad.NonceSize())
+			cipherText, tag := ad.SealDetached(nonce, plainText, additionalData)
+			require.Len(t, cipherText, len(plainText), name)
+			require.Len(t, tag, ad.Overhead(), name)
+
+			sealed := ad.Seal(nil, nonce, plainText, additionalData)
+			if name == "siv" {
+				assert.Equal(t, sealed, append(append([]byte{}, tag...), cipherText...), name)
+			} else {
+				assert.Equal(t, sealed, append(append([]byte{}, cipherText...), tag...), name)
+			}
+
+			got, err := ad.OpenDetached(nonce, cipherText, tag, additionalData)
+			require.NoError(t, err, name)
+			assert.Equal(t, plainText, got, name)
+
+			_, err = ad.OpenDetached(nonce, cipherText, tag[:len(tag)-1], additionalData)
+			require.Equal(t, ErrAuthFailed, err, name)
+			tag[0] ^= 0x01
+			_, err = ad.OpenDetached(nonce, cipherText, tag, additionalData)
+			require.Equal(t, ErrAuthFailed, err, name)
+		}
+	})
+
+	t.Run("open in place", func(t *testing.T) {
+		gcm, err := NewAEAD(key[:], aes.NewCipher)
+		require.NoError(t, err)
+		siv, err := NewSIV(key[:])
+		require.NoError(t, err)
+		gcmSIV, err := NewGCMSIV(key[:])
+		require.NoError(t, err)
+		ccm, err := NewCCM(key[:16], 12, 16, aes.NewCipher)
+		require.NoError(t, err)
+		eax, err := NewEAX(key[:16], 16, aes.NewCipher)
+		require.NoError(t, err)
+		chacha, err := NewChaCha20Poly1305(key[:])
+		require.NoError(t, err)
+
+		for name, ad := range map[string]AEADCrypt{"gcm": gcm, "siv": siv, "gcm-siv": gcmSIV, "ccm": ccm, "eax": eax, "chacha20-poly1305": chacha} {
+			nonce := make([]byte, ad.NonceSize())
+			for _, pt := range [][]byte{plainText, {}} {
+				cipherText := ad.Seal(nil, nonce, pt, additionalData)
+				want, err := ad.Open(nil, nonce, cipherText, additionalData)
+				require.NoError(t, err, name)
+
+				got, err := ad.OpenInPlace(nonce, cipherText, additionalData)
+				require.NoError(t, err, name)
+				assert.Equal(t, want, got, name)
+				if len(pt) > 0 {
+					// the plain text shares the cipher text's storage
+					offset := 0
+					if name == "siv" {
+						offset = ad.Overhead()
+					}
+					assert.True(t, &got[0] == &cipherText[offset], name)
+				}
+			}
+
+			cipherText := ad.Seal(nil, nonce, plainText, additionalData)
+			cipherText[len(cipherText)/2] ^= 0x01
+			_, err = ad.OpenInPlace(nonce, cipherText, additionalData)
+			require.Equal(t, ErrAuthFailed, err, name)
+			_, err = ad.OpenInPlace(nonce, cipherText[:ad.Overhead()-1], additionalData)
+			require.Equal(t, ErrCipherTextTooShort, err, name)
+		}
+	})
+
+	t.Run("auth failed", func(t *testing.T) {
+		ad, err := NewAEAD(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText := ad.Seal(nil, nonce, plainText, additionalData)
+
+		_, err = ad.Open(nil, nonce, cipherText, []byte("other data"))
+		require.Equal(t, ErrAuthFailed, err)
+
+		cipherText[0] ^= 0x01
+		_, err = ad.Open(nil, nonce, cipherText, additionalData)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("invalid nonce length", func(t *testing.T) {
+		ad, err := NewAEAD(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+
+		require.Panics(t, func() { ad.Seal(nil, []byte{}, plainText, nil) })
+		_, err = ad.Open(nil, []byte{}, plainText, nil)
+		require.Equal(t, ErrInvalidNonceSize, err)
+	})
+	t.Run("invalid cipher", func(t *testing.T) {
+		_, err := NewAEAD(key[:16], mockErrorNewCipher)
+		require.Error(t, err)
+	})
+}
+
+func TestBuildAAD(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 2, 'a', 'b', 0, 0, 0, 0, 0, 0, 0, 1, 'c'},
+		BuildAAD([]byte("ab"), []byte("c")))
+	assert.NotEqual(t, BuildAAD([]byte("ab"), []byte("c")), BuildAAD([]byte("a"), []byte("bc")))
+	assert.NotEqual(t, BuildAAD([]byte("abc")), BuildAAD([]byte("abc"), nil))
+	assert.NotEqual(t, BuildAAD(nil), BuildAAD())
+	assert.Empty(t, BuildAAD())
+
+	key := sha256.Sum256([]byte("secret_key"))
+	ad, err := NewAEAD(key[:16], aes.NewCipher)
+	require.NoError(t, err)
+	blob, err := ad.SealRandom([]byte("helloworld"), BuildAAD([]byte("ab"), []byte("c")))
+	require.NoError(t, err)
+	got, err := ad.OpenRandom(blob, BuildAAD([]byte("ab"), []byte("c")))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("helloworld"), got)
+	_, err = ad.OpenRandom(blob, BuildAAD([]byte("a"), []byte("bc")))
+	require.Equal(t, ErrAuthFailed, err)
+}
+
+func TestAEADCrypt(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("default gcm", func(t *testing.T) {
+		ad, err := NewAEADCrypt(key[:], 12, aes.NewCipher)
+		require.NoError(t, err)
+		want, err := NewAEAD(key[:], aes.NewCipher)
+		require.NoError(t, err)
+
+		nonce := make([]byte, 12)
+		assert.Equal(t, want.Seal(nil, nonce, plainText, nil), ad.Seal(nil, nonce, plainText, nil))
+	})
+
+	t.Run("custom aead", func(t *testing.T) {
+		newEAX := func(block cipher.Block) (cipher.AEAD, error) {
+			return &eax{block, newCMAC(block), 16, 16}, nil
+		}
+		ad, err := NewAEADCrypt(key[:], 16, aes.NewCipher, WithAEAD(newEAX))
+		require.NoError(t, err)
+		want, err := NewEAX(key[:], 16, aes.NewCipher)
+		require.NoError(t, err)
+
+		nonce := make([]byte, 16)
+		cipherText := ad.Seal(nil, nonce, plainText, []byte("header"))
+		assert.Equal(t, want.Seal(nil, nonce, plainText, []byte("header")), cipherText)
+		got, err := ad.Open(nil, nonce, cipherText, []byte("header"))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		blob, err := ad.SealRandom(plainText, nil)
+		require.NoError(t, err)
+		got, err = ad.OpenRandom(blob, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewAEADCrypt(key[:], 16, aes.NewCipher)
+		require.Equal(t, ErrInvalidNonceSize, err)
+		_, err = NewAEADCrypt(key[:], 12, mockErrorNewCipher)
+		require.Error(t, err)
+		_, err = NewAEADCrypt(key[:8], 12, des.NewCipher)
+		require.Error(t, err)
+	})
+}
+
+func BenchmarkOpen(b *testing.B) {
+	key := sha256.Sum256([]byte("secret_key"))
+	ad, err := NewAEAD(key[:16], aes.NewCipher)
+	require.NoError(b, err)
+
+	nonce := make([]byte, ad.NonceSize())
+	sealed := ad.Seal(nil, nonce, make([]byte, 1<<10), nil)
+	cipherText := make([]byte, len(sealed))
+	b.Run("open", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(sealed)))
+		for i := 0; i < b.N; i++ {
+			_, _ = ad.Open(nil, nonce, sealed, nil)
+		}
+	})
+	b.Run("open in place", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(sealed)))
+		for i := 0; i < b.N; i++ {
+			copy(cipherText, sealed)
+			_, _ = ad.OpenInPlace(nonce, cipherText, nil)
+		}
+	})
+}
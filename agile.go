@@ -0,0 +1,93 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/aes"
+	"errors"
+)
+
+// ErrNoPrimaryKey the primary algorithm has no key
+var ErrNoPrimaryKey = errors.New("primary algorithm key not found")
+
+// AgileAEAD crypto-agile aead, the blob is self-describing, the format:
+//
+//	algorithm id(1) | nonce(the algorithm's nonce size) | cipher text with tag
+//
+// Seal always use the primary algorithm, Open reads the algorithm id and selects the matched one,
+// so the algorithm can be migrated without re-encrypting the stored blob at once.
+type AgileAEAD interface {
+	// Seal seal the plain text with the primary algorithm and a fresh random nonce.
+	Seal(plainText, additionalData []byte) ([]byte, error)
+	// Open open the blob sealed by any of the configured algorithms,
+	// return ErrUnknownAlgorithm if the algorithm of the blob is not configured.
+	Open(blob, additionalData []byte) ([]byte, error)
+}
+
+// NewAgileAEAD new crypto-agile aead with the primary algorithm, the keys of algorithm id and custom option.
+// the algorithm support:
+//      AlgorithmAESGCM: 16, 24 or 32 bytes key
+//      AlgorithmChaCha20Poly1305: 32 bytes key
+//      AlgorithmXChaCha20Poly1305: 32 bytes key
+//      AlgorithmAESSIV: 32, 48 or 64 bytes key
+//      AlgorithmAESGCMSIV: 16 or 32 bytes key
+// return ErrUnknownAlgorithm if any of the algorithm is not supported, ErrNoPrimaryKey if the
+// primary algorithm has no key.
+// option support: WithRand
+func NewAgileAEAD(primary Algorithm, keys map[Algorithm][]byte, opts ...Option) (AgileAEAD, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, ErrNoPrimaryKey
+	}
+	aeads := make(map[Algorithm]AEADCrypt, len(keys))
+	for alg, key := range keys {
+		var ad AEADCrypt
+		var err error
+		switch alg {
+		case AlgorithmAESGCM:
+			ad, err = NewAEAD(key, aes.NewCipher, opts...)
+		case AlgorithmChaCha20Poly1305:
+			ad, err = NewChaCha20Poly1305(key, opts...)
+		case AlgorithmXChaCha20Poly1305:
+			ad, err = NewChaCha20Poly1305(key, append([]Option{WithXChaCha20()}, opts...)...)
+		case AlgorithmAESSIV:
+			ad, err = NewSIV(key, opts...)
+		case AlgorithmAESGCMSIV:
+			ad, err = NewGCMSIV(key, opts...)
+		default:
+			return nil, ErrUnknownAlgorithm
+		}
+		if err != nil {
+			return nil, err
+		}
+		aeads[alg] = ad
+	}
+	return &agileAEAD{primary, aeads}, nil
+}
+
+type agileAEAD struct {
+	primary Algorithm
+	aeads   map[Algorithm]AEADCrypt
+}
+
+// Seal implement AgileAEAD
+func (sf *agileAEAD) Seal(plainText, additionalData []byte) ([]byte, error) {
+	blob, err := sf.aeads[sf.primary].SealRandom(plainText, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(sf.primary)}, blob...), nil
+}
+
+// Open implement AgileAEAD
+func (sf *agileAEAD) Open(blob, additionalData []byte) ([]byte, error) {
+	if len(blob) < 1 {
+		return nil, ErrCipherTextTooShort
+	}
+	ad, ok := sf.aeads[Algorithm(blob[0])]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+	return ad.OpenRandom(blob[1:], additionalData)
+}
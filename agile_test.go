@@ -0,0 +1,85 @@
+package aesext
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgileAEAD(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+	keys := map[Algorithm][]byte{
+		AlgorithmAESGCM:            bytes.Repeat([]byte{0x01}, 32),
+		AlgorithmChaCha20Poly1305:  bytes.Repeat([]byte{0x02}, 32),
+		AlgorithmXChaCha20Poly1305: bytes.Repeat([]byte{0x03}, 32),
+		AlgorithmAESSIV:            bytes.Repeat([]byte{0x04}, 64),
+		AlgorithmAESGCMSIV:         bytes.Repeat([]byte{0x05}, 16),
+	}
+
+	t.Run("auto detect", func(t *testing.T) {
+		receiver, err := NewAgileAEAD(AlgorithmAESGCM, keys)
+		require.NoError(t, err)
+		for alg := range keys {
+			sender, err := NewAgileAEAD(alg, keys)
+			require.NoError(t, err)
+			blob, err := sender.Seal(plainText, []byte("header"))
+			require.NoError(t, err)
+			assert.Equal(t, byte(alg), blob[0])
+			assert.Equal(t, 1+envelopeNonceSizes[alg]+len(plainText)+16, len(blob))
+
+			got, err := receiver.Open(blob, []byte("header"))
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+
+			_, err = receiver.Open(blob, nil)
+			require.Equal(t, ErrAuthFailed, err)
+		}
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		old, err := NewAgileAEAD(AlgorithmAESGCM, map[Algorithm][]byte{AlgorithmAESGCM: keys[AlgorithmAESGCM]})
+		require.NoError(t, err)
+		oldBlob, err := old.Seal(plainText, nil)
+		require.NoError(t, err)
+
+		migrated, err := NewAgileAEAD(AlgorithmXChaCha20Poly1305, map[Algorithm][]byte{
+			AlgorithmAESGCM:            keys[AlgorithmAESGCM],
+			AlgorithmXChaCha20Poly1305: keys[AlgorithmXChaCha20Poly1305],
+		})
+		require.NoError(t, err)
+		newBlob, err := migrated.Seal(plainText, nil)
+		require.NoError(t, err)
+		assert.Equal(t, byte(AlgorithmXChaCha20Poly1305), newBlob[0])
+
+		for _, blob := range [][]byte{oldBlob, newBlob} {
+			got, err := migrated.Open(blob, nil)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+		_, err = old.Open(newBlob, nil)
+		require.Equal(t, ErrUnknownAlgorithm, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewAgileAEAD(AlgorithmChaCha20Poly1305, map[Algorithm][]byte{AlgorithmAESGCM: keys[AlgorithmAESGCM]})
+		require.Equal(t, ErrNoPrimaryKey, err)
+		_, err = NewAgileAEAD(AlgorithmAESCBC, map[Algorithm][]byte{AlgorithmAESCBC: keys[AlgorithmAESGCM]})
+		require.Equal(t, ErrUnknownAlgorithm, err)
+		_, err = NewAgileAEAD(AlgorithmAESGCM, map[Algorithm][]byte{AlgorithmAESGCM: make([]byte, 10)})
+		require.Error(t, err)
+
+		ad, err := NewAgileAEAD(AlgorithmAESGCM, keys)
+		require.NoError(t, err)
+		_, err = ad.Open(nil, nil)
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = ad.Open([]byte{byte(AlgorithmAESGCM), 0x01}, nil)
+		require.Equal(t, ErrCipherTextTooShort, err)
+
+		ad, err = NewAgileAEAD(AlgorithmAESGCM, keys, WithRand(bytes.NewReader(nil)))
+		require.NoError(t, err)
+		_, err = ad.Seal(plainText, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+	"io"
+)
+
+// NewAuthEncryptWriter returns a writer, it encrypts as NewEncryptWriter, and computes a running
+// HMAC-SHA256 over all the output(including the iv header if any), the 32 bytes tag is appended on Close,
+// so the stream is authenticated without holding the whole message in memory.
+// encryption key and macKey should be independent keys, macKey should be at least 32 bytes.
+// Close must be called to flush the final block and the tag, it does not close the underlying writer.
+// bc must be created by this package, otherwise all the writes return ErrStreamNotSupported.
+func NewAuthEncryptWriter(w io.Writer, bc BlockCrypt, macKey []byte) io.WriteCloser {
+	mac := hmac.New(sha256.New, macKey)
+	return &authEncryptWriter{
+		enc: NewEncryptWriter(io.MultiWriter(w, mac), bc),
+		w:   w,
+		mac: mac,
+	}
+}
+
+type authEncryptWriter struct {
+	enc    io.WriteCloser
+	w      io.Writer
+	mac    hash.Hash
+	closed bool
+}
+
+// Write implement io.Writer
+func (sf *authEncryptWriter) Write(p []byte) (int, error) {
+	return sf.enc.Write(p)
+}
+
+// Close flush the final block with padding and the tag, it does not close the underlying writer.
+func (sf *authEncryptWriter) Close() error {
+	if err := sf.enc.Close(); err != nil || sf.closed {
+		return err
+	}
+	sf.closed = true
+	_, err := sf.w.Write(sf.mac.Sum(nil))
+	return err
+}
+
+// NewAuthDecryptReader returns a reader, it reads the stream written by NewAuthEncryptWriter from r,
+// the last 32 bytes is held back as the tag, and verified after the whole stream read,
+// the final Read returns ErrMACMismatch instead of io.EOF if the tag doesn't verify.
+// NOTE: the plain text is returned before the tag verified, the caller must discard
+// all the data read if the final Read returns an error.
+// bc must be created by this package, otherwise all the reads return ErrStreamNotSupported.
+func NewAuthDecryptReader(r io.Reader, bc BlockCrypt, macKey []byte) io.Reader {
+	return NewDecryptReader(&macReader{
+		r:     r,
+		mac:   hmac.New(sha256.New, macKey),
+		chunk: make([]byte, 4096),
+	}, bc)
+}
+
+// macReader hold back the trailing tag, feed the rest to the mac, verify the tag at EOF.
+type macReader struct {
+	r     io.Reader
+	mac   hash.Hash
+	chunk []byte
+	buf   []byte // data not be read, the last sha256.Size bytes may be the tag
+	eof   bool
+	err   error
+}
+
+// Read implement io.Reader
+func (sf *macReader) Read(p []byte) (int, error) {
+	for {
+		if sf.err != nil {
+			return 0, sf.err
+		}
+		if n := len(sf.buf) - sha256.Size; n > 0 {
+			n = copy(p, sf.buf[:n])
+			sf.mac.Write(sf.buf[:n]) // nolint: errcheck
+			sf.buf = append(sf.buf[:0], sf.buf[n:]...)
+			return n, nil
+		}
+		if sf.eof {
+			sf.err = io.EOF
+			if len(sf.buf) != sha256.Size || !constantTimeEqual(sf.buf, sf.mac.Sum(nil)) {
+				sf.err = ErrMACMismatch
+			}
+			continue
+		}
+		n, err := sf.r.Read(sf.chunk)
+		sf.buf = append(sf.buf, sf.chunk[:n]...)
+		switch {
+		case err == io.EOF:
+			sf.eof = true
+		case err != nil:
+			sf.err = err
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthStream(t *testing.T) {
+	encKey := []byte("0123456789abcdef")
+	macKey := []byte("0123456789abcdef0123456789abcdef")
+	plainText := make([]byte, 10000)
+	for i := range plainText {
+		plainText[i] = byte(i * 7)
+	}
+
+	bc, err := NewBlockCryptRandomIV(encKey, aes.NewCipher)
+	require.NoError(t, err)
+	ctr, err := NewCTRCrypt(encKey, encKey, aes.NewCipher)
+	require.NoError(t, err)
+
+	seal := func(t *testing.T, bc BlockCrypt, data []byte) []byte {
+		out := &bytes.Buffer{}
+		w := NewAuthEncryptWriter(out, bc, macKey)
+		for len(data) > 0 {
+			n := 333
+			if n > len(data) {
+				n = len(data)
+			}
+			_, err := w.Write(data[:n])
+			require.NoError(t, err)
+			data = data[n:]
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close())
+		return out.Bytes()
+	}
+	open := func(bc BlockCrypt, stream []byte) ([]byte, error) {
+		return ioutil.ReadAll(NewAuthDecryptReader(iotest.HalfReader(bytes.NewReader(stream)), bc, macKey))
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, crypt := range []BlockCrypt{bc, ctr} {
+			for _, size := range []int{0, 1, 15, 16, 17, 4096, len(plainText)} {
+				got, err := open(crypt, seal(t, crypt, plainText[:size]))
+				require.NoError(t, err)
+				assert.True(t, bytes.Equal(plainText[:size], got))
+			}
+		}
+	})
+
+	t.Run("encrypt then mac compatible", func(t *testing.T) {
+		etm, err := NewEncryptThenMAC(encKey, macKey, aes.NewCipher)
+		require.NoError(t, err)
+
+		got, err := etm.Decrypt(seal(t, bc, plainText))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		cipherText, err := etm.Encrypt(plainText)
+		require.NoError(t, err)
+		got, err = open(bc, cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		for _, crypt := range []BlockCrypt{bc, ctr} {
+			stream := seal(t, crypt, plainText[:100])
+			for _, i := range []int{0, 20, len(stream) - 1} {
+				tampered := append([]byte{}, stream...)
+				tampered[i] ^= 0x01
+				_, err := open(crypt, tampered)
+				require.Equal(t, ErrMACMismatch, err)
+			}
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		stream := seal(t, bc, plainText[:100])
+		for _, n := range []int{0, 1, 31, 32, len(stream) - 16, len(stream) - 1} {
+			_, err := open(bc, stream[:n])
+			require.Equal(t, ErrMACMismatch, err)
+		}
+	})
+
+	t.Run("wrong mac key", func(t *testing.T) {
+		stream := seal(t, bc, plainText[:100])
+		_, err := ioutil.ReadAll(NewAuthDecryptReader(bytes.NewReader(stream), bc, macKey[:16]))
+		require.Equal(t, ErrMACMismatch, err)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		want := errors.New("read error")
+		_, err := ioutil.ReadAll(NewAuthDecryptReader(iotest.TimeoutReader(iotest.OneByteReader(bytes.NewReader(plainText))), bc, macKey))
+		require.Equal(t, iotest.ErrTimeout, err)
+		_, err = ioutil.ReadAll(NewAuthDecryptReader(io.MultiReader(bytes.NewReader(plainText), &errReader{want}), bc, macKey))
+		require.Equal(t, want, err)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		etm, err := NewEncryptThenMAC(encKey, macKey, aes.NewCipher)
+		require.NoError(t, err)
+		w := NewAuthEncryptWriter(&bytes.Buffer{}, etm, macKey)
+		_, err = w.Write(plainText)
+		require.Equal(t, ErrStreamNotSupported, err)
+		require.Equal(t, ErrStreamNotSupported, w.Close())
+		_, err = open(etm, seal(t, bc, plainText))
+		require.Equal(t, ErrStreamNotSupported, err)
+	})
+}
+
+type errReader struct{ err error }
+
+func (sf *errReader) Read([]byte) (int, error) { return 0, sf.err }
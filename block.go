@@ -5,9 +5,15 @@
 package aesext
 
 import (
-	"bytes"
 	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
 )
 
 // error defined
@@ -15,26 +21,310 @@ var (
 	ErrInputNotMultipleBlocks = errors.New("decoded message length must be multiple of block size")
 	ErrInvalidIvSize          = errors.New("iv length must equal block size")
 	ErrUnPaddingOutOfRange    = errors.New("unPadding out of range")
+	ErrInvalidPadding         = errors.New("invalid padding")
+	ErrCipherTextTooShort     = errors.New("cipher text too short")
+	ErrCipherTextTooLarge     = errors.New("cipher text too large")
+	ErrInvalidBlockSize       = errors.New("cipher block size must be positive")
+	ErrNoCipherFactory        = errors.New("no cipher factory to rebuild the block")
+	ErrWeakIV                 = errors.New("iv must not be all zero")
 )
 
+// IvSizeError the iv length mismatch error, it tells the given and the expected length,
+// and matches ErrInvalidIvSize by errors.Is.
+type IvSizeError struct {
+	Size      int // the given iv length
+	BlockSize int // the expected iv length
+}
+
+func (sf *IvSizeError) Error() string {
+	return fmt.Sprintf("iv length %d must equal block size %d", sf.Size, sf.BlockSize)
+}
+
+// Unwrap returns ErrInvalidIvSize
+func (sf *IvSizeError) Unwrap() error { return ErrInvalidIvSize }
+
 // BlockCrypt block crypt interface
+// Encrypt and Decrypt are safe for concurrent use by multiple goroutines,
+// each call creates its own cipher.BlockMode and never modifies the shared state.
+// empty plain text is supported by all the modes, Decrypt(Encrypt(nil)) returns an empty slice,
+// the padding modes encrypt it to a full padding block, the stream modes, WithNoPadding and
+// the Zero padding to empty.
 type BlockCrypt interface {
 	// BlockSize returns the mode's block size.
 	BlockSize() int
 	// Encrypt plain text. return cipher text, not contains iv.
+	// the plain text is never modified.
 	Encrypt(plainText []byte) ([]byte, error)
-	// Encrypt cipher text cipher text. plain text, not contains iv.
+	// Decrypt cipher text. return plain text, not contains iv.
+	// the cipher text is never modified, and the plain text is an independent copy,
+	// which never shares memory with the cipher text, so it is safe to hold after reuse the cipher text.
 	Decrypt(cipherText []byte) ([]byte, error)
+	// EncryptTo encrypt plain text, appends the cipher text to dst and returns the updated slice.
+	// dst and plain text must not overlap. It enables reusing dst, avoid allocations.
+	EncryptTo(dst, plainText []byte) ([]byte, error)
+	// DecryptTo decrypt cipher text, appends the plain text to dst and returns the updated slice.
+	// To reuse cipher text's storage for the plain text, use cipherText[:0] as dst,
+	// otherwise, dst and cipher text must not overlap.
+	DecryptTo(dst, cipherText []byte) ([]byte, error)
+	// Clone returns an independent copy, which shares the immutable cipher.Block
+	// but deep copy the other state, such as iv. It is cheaper than rebuilding the cipher from the key.
+	Clone() BlockCrypt
+}
+
+// IVAccessor the crypt which holds a fixed iv implement it, such as the BlockCrypt
+// created by NewBlockCrypt, NewBlockCryptWithBlock, NewCFBCrypt and NewOFBCrypt.
+// SetIV is not safe for concurrent use with Encrypt and Decrypt.
+type IVAccessor interface {
+	// IV returns a copy of the current iv, modify it does not affect the crypt.
+	IV() []byte
+	// SetIV rotate the iv, the iv is copied, return *IvSizeError if the length not equal BlockSize(),
+	// ErrWeakIV if the iv is all zero with WithStrictIV.
+	SetIV(iv []byte) error
+	// EncryptWithIV encrypt plain text with the iv for this single call, the stored iv is untouched.
+	// return cipher text, not contains iv. it is handy when the iv is transferred out of band.
+	EncryptWithIV(iv, plainText []byte) ([]byte, error)
+	// DecryptWithIV decrypt cipher text with the iv for this single call, the stored iv is untouched.
+	DecryptWithIV(iv, cipherText []byte) ([]byte, error)
+}
+
+// BlockAccessor the crypt created by NewBlockCrypt, NewBlockCryptWithBlock, NewBlockCryptRandomIV,
+// NewCFBCrypt and NewOFBCrypt implement it.
+type BlockAccessor interface {
+	// Block returns the underlying cipher.Block, for building a custom mode.
+	// the block shares the key state with the crypt, it must not be modified.
+	Block() cipher.Block
+}
+
+// BatchEncrypter the crypt created by NewBlockCrypt, NewBlockCryptWithBlock, NewBlockCryptRandomIV,
+// NewCFBCrypt and NewOFBCrypt implement it.
+type BatchEncrypter interface {
+	// EncryptBatch encrypt many independent plain texts, reuse the internal scratch space across the batch.
+	// return cipher texts in order, each one is the same as Encrypt.
+	EncryptBatch(plainTexts [][]byte) ([][]byte, error)
+}
+
+// KeySetter the crypt created by NewBlockCrypt, NewBlockCryptRandomIV, NewCFBCrypt
+// and NewOFBCrypt implement it.
+// SetKey is not safe for concurrent use with Encrypt and Decrypt.
+type KeySetter interface {
+	// SetKey rotate the key in place, rebuild the cipher.Block via the newCipher factory,
+	// return the factory's error on bad key size, ErrNoCipherFactory if the crypt is created
+	// by NewBlockCryptWithBlock, ErrInvalidBlockSize if the new block size differs.
+	SetKey(key []byte) error
+}
+
+// KeyFingerprinter the crypt created by NewBlockCrypt, NewBlockCryptRandomIV, NewCFBCrypt
+// and NewOFBCrypt implement it.
+type KeyFingerprinter interface {
+	// KeyFingerprint returns the first 8 hex chars of the key's sha256, for correlating the key in the logs
+	// without logging the key, it follows SetKey. return empty if the key is unknown, such as
+	// created by NewBlockCryptWithBlock.
+	// NOTE: it is a public identifier, it is safe for the random key only, the key derived from
+	// a password can be brute forced against it.
+	KeyFingerprint() string
+}
+
+// IVSplitter the crypt created by NewBlockCrypt, NewBlockCryptWithBlock, NewBlockCryptRandomIV,
+// NewCFBCrypt and NewOFBCrypt implement it.
+type IVSplitter interface {
+	// SplitIV split the blob into the prepended iv of BlockSize() bytes and the rest,
+	// return ErrCipherTextTooShort if the blob is shorter than BlockSize().
+	// both share the blob's storage, no copy.
+	SplitIV(blob []byte) (iv, rest []byte, err error)
+}
+
+// PaddingVerifier the crypt created by NewBlockCrypt, NewBlockCryptWithBlock, NewBlockCryptRandomIV,
+// NewCFBCrypt and NewOFBCrypt implement it, for triaging the decryption failures.
+// NOTE: it reveals whether the padding is valid, which is a padding oracle, never expose it to
+// the untrusted party, use Decrypt in production.
+type PaddingVerifier interface {
+	// DecryptVerify decrypt cipher text, it separates the padding failure from the structural one:
+	// return err if the cipher text is structurally invalid, such as ErrInputNotMultipleBlocks
+	// and ErrCipherTextTooShort, otherwise paddingValid reports whether the padding is valid,
+	// if not, which usually means a wrong key or iv, the raw decrypted output is returned with padding.
+	// stream mode and WithNoPadding always report paddingValid true.
+	DecryptVerify(cipherText []byte) (plainText []byte, paddingValid bool, err error)
+}
+
+// Padder the crypt created by NewBlockCrypt, NewBlockCryptWithBlock, NewBlockCryptRandomIV,
+// NewCFBCrypt and NewOFBCrypt implement it, it exposes the configured padding scheme,
+// so the exact bytes to be encrypted can be inspected when debugging interop mismatches.
+// stream mode and WithNoPadding have no padding, Pad and UnPad return the data unchanged.
+type Padder interface {
+	// Pad returns a padded copy of data to a multiple of BlockSize(), data is never modified.
+	Pad(data []byte) []byte
+	// UnPad removes the padding from data, return the padding error, such as ErrInvalidPadding.
+	UnPad(data []byte) ([]byte, error)
 }
 
 // Option option
-type Option func(bs *blockBlock)
+// the options are shared by all the constructors, the option which is not
+// applicable to the constructor is ignored.
+type Option func(c *config)
+
+// config the crypt configuration
+type config struct {
+	newEncrypt func(block cipher.Block, iv []byte) cipher.BlockMode
+	newDecrypt func(block cipher.Block, iv []byte) cipher.BlockMode
+	padding    Padding
+	rand       io.Reader
+	// stream mode, no padding, no block size alignment
+	stream bool
+	// no padding, but the data must be block size alignment
+	noPadding bool
+	// max cipher text size for decrypt, 0 means unlimited
+	maxCipherSize int
+	// prepend the iv to the cipher text
+	ivPrefix bool
+	// reject the all-zero iv
+	strictIV bool
+	// pad the plain text to at least minPadTo bytes, 0 means the normal padding
+	minPadTo int
+	// aead
+	newAEAD      func(block cipher.Block) (cipher.AEAD, error)
+	gcmNonceSize int
+	gcmTagSize   int
+	eaxTagSize   int
+	xchacha20    bool
+	// password
+	argon2Time    uint32
+	argon2Memory  uint32
+	argon2Threads uint8
+	// gcm stream
+	segmentSize int
+}
+
+func newConfig(opts ...Option) config {
+	c := config{
+		newEncrypt:   cipher.NewCBCEncrypter,
+		newDecrypt:   cipher.NewCBCDecrypter,
+		padding:      PKCS7{},
+		rand:         rand.Reader,
+		newAEAD:      cipher.NewGCM,
+		gcmNonceSize: gcmStandardNonceSize,
+		gcmTagSize:   gcmStandardTagSize,
+		eaxTagSize:   eaxBlockSize,
+
+		argon2Time:    defaultArgon2Time,
+		argon2Memory:  defaultArgon2Memory,
+		argon2Threads: defaultArgon2Thread,
+
+		segmentSize: defaultSegmentSize,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.minPadTo > 0 {
+		c.padding = minPadTo{c.minPadTo}
+	}
+	// the random padding without its own random source use the shared one.
+	if p, ok := c.padding.(ISO10126); ok && p.Rand == nil {
+		c.padding = ISO10126{c.rand}
+	}
+	return c
+}
 
 // WithBlockCodec option encrypt and decrypt
+// the codec must not modify or retain the iv, which is shared by all the calls.
 func WithBlockCodec(newEncrypt, newDecrypt func(block cipher.Block, iv []byte) cipher.BlockMode) Option {
-	return func(bs *blockBlock) {
-		bs.newEncrypt = newEncrypt
-		bs.newDecrypt = newDecrypt
+	return func(c *config) {
+		c.newEncrypt = newEncrypt
+		c.newDecrypt = newDecrypt
+	}
+}
+
+// WithStreamCodec option stream encrypt and decrypt, such as ctr, ofb, cfb.
+// the codec must not modify or retain the iv, which is shared by all the calls.
+// stream mode not need padding, so the cipher text length equal plain text length.
+func WithStreamCodec(newEncrypt, newDecrypt func(block cipher.Block, iv []byte) cipher.Stream) Option {
+	return func(c *config) {
+		c.newEncrypt = streamBlockMode(newEncrypt)
+		c.newDecrypt = streamBlockMode(newDecrypt)
+		c.stream = true
+	}
+}
+
+// WithStreamMode option mark the mode as stream mode, Encrypt skip padding and
+// Decrypt skip block size alignment check.
+// WithStreamCodec has been implied it, use it when the codec set by WithBlockCodec
+// can process arbitrary length data, it is the option for a stream cipher adapted to
+// cipher.BlockMode and plugged into WithBlockCodec, such as ctr.
+func WithStreamMode() Option {
+	return func(c *config) {
+		c.stream = true
+	}
+}
+
+// WithPadding option padding scheme, default PKCS7.
+// stream mode ignore it.
+func WithPadding(p Padding) Option {
+	return func(c *config) {
+		c.padding = p
+	}
+}
+
+// WithNoPadding option skip padding for the pre-aligned data, Encrypt return
+// ErrInputNotMultipleBlocks if the plain text is not multiple of block size,
+// Decrypt return the raw block output unchanged.
+// stream mode ignore it.
+func WithNoPadding() Option {
+	return func(c *config) {
+		c.noPadding = true
+	}
+}
+
+// WithMinPadTo option pad the plain text to at least n bytes, rounded up to the block size multiple,
+// it hides the message length, such as the next multiple of 256 bytes, n <= 0 means the normal padding.
+// it replaces the padding scheme set by WithPadding, both sides must use it with the same n:
+//	if the padding length <= 255, it is PKCS#7 padding, which may be longer than a block,
+//	otherwise it is zero fill | plain text length(8 bytes big endian) | 0x00, which can't be ambiguous
+//	with PKCS#7, since the last byte of PKCS#7 is never zero.
+// stream mode and WithNoPadding ignore it, NewEncryptWriter and NewDecryptReader not support it,
+// since the padding may exceed the final block. MarshalBinary transfers n with the padding scheme.
+func WithMinPadTo(n int) Option {
+	return func(c *config) {
+		c.minPadTo = n
+	}
+}
+
+// WithMaxCipherSize option max cipher text size in bytes for decrypt, default unlimited,
+// Decrypt return ErrCipherTextTooLarge before doing any work if the cipher text exceeds it,
+// it protects the server accepting untrusted input against memory exhaustion.
+// n <= 0 means unlimited.
+func WithMaxCipherSize(n int) Option {
+	return func(c *config) {
+		c.maxCipherSize = n
+	}
+}
+
+// WithIVPrefix option Encrypt prepend the configured iv to the cipher text,
+// Decrypt consume the first BlockSize() bytes as the iv, so the cipher text is self-contained.
+// a caller can regenerate the iv per message by SetIV, and the receiver need not know it.
+// random iv mode has been implied it.
+func WithIVPrefix() Option {
+	return func(c *config) {
+		c.ivPrefix = true
+	}
+}
+
+// WithStrictIV option reject the all-zero iv, which is usually an uninitialized one,
+// NewBlockCrypt, NewBlockCryptWithBlock, SetIV and UnmarshalBinary return ErrWeakIV for it, default off.
+// random iv mode ignore it.
+func WithStrictIV() Option {
+	return func(c *config) {
+		c.strictIV = true
+	}
+}
+
+// WithRand option random source, default crypto/rand.Reader.
+// it is respected everywhere the package draws random bytes, such as the random iv,
+// the aead random nonce, the NonceSequence prefix, the gcm stream nonce prefix, the openssl salt and the ISO10126 padding
+// without its own random source, so tests can inject a deterministic reader, such as ZeroRand and SequentialRand, and
+// FIPS environments can supply their own DRBG. a read failure of it is returned by the call, such as Encrypt,
+// except the Padder.Pad and the ISO10126Padding function, which keep 0x00 for the ISO10126 padding.
+func WithRand(r io.Reader) Option {
+	return func(c *config) {
+		c.rand = r
 	}
 }
 
@@ -50,32 +340,95 @@ func WithBlockCodec(newEncrypt, newDecrypt func(block cipher.Block, iv []byte) c
 // 		tea
 // support:
 //      cbc(default): cipher.NewCBCEncrypter, cipher.NewCBCDecrypter
+//      ctr: WithStreamCodec(cipher.NewCTR, cipher.NewCTR)
+//      ofb: WithStreamCodec(cipher.NewOFB, cipher.NewOFB)
+//      cfb: WithStreamCodec(cipher.NewCFBEncrypter, cipher.NewCFBDecrypter)
 func NewBlockCrypt(key, iv []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (BlockCrypt, error) {
-	block, err := newCipher(key)
+	block, err := newBlock(newCipher, key)
 	if err != nil {
 		return nil, err
 	}
-	if len(iv) != block.BlockSize() {
-		return nil, ErrInvalidIvSize
+	bc, err := NewBlockCryptWithBlock(block, iv, opts...)
+	if err != nil {
+		return nil, err
 	}
+	bb := bc.(*blockBlock)
+	bb.newCipher, bb.keyLen, bb.fingerprint = newCipher, len(key), keyFingerprint(key)
+	return bb, nil
+}
 
+// NewBlockCryptWithBlock new with the cipher.Block, iv and custom option, see NewBlockCrypt.
+// it is useful when the block is constructed elsewhere, such as a hardware-backed one,
+// the block can be shared by multiple BlockCrypt with different iv.
+func NewBlockCryptWithBlock(block cipher.Block, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if block.BlockSize() <= 0 {
+		return nil, ErrInvalidBlockSize
+	}
 	bb := &blockBlock{
-		block:      block,
-		iv:         iv,
-		newEncrypt: cipher.NewCBCEncrypter,
-		newDecrypt: cipher.NewCBCDecrypter,
+		block:  block,
+		config: newConfig(opts...),
 	}
-	for _, opt := range opts {
-		opt(bb)
+	if err := bb.checkIV(iv); err != nil {
+		return nil, err
 	}
+	bb.iv = append([]byte{}, iv...)
 	return bb, nil
 }
 
+// NewBlockCryptRandomIV new with newCipher, key and custom option,
+// each Encrypt generate a fresh random iv from random source(see WithRand),
+// and prepend it to the cipher text, Decrypt read the first BlockSize() bytes as the iv.
+// so the same plain text encrypt to different cipher text.
+func NewBlockCryptRandomIV(key []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (BlockCrypt, error) {
+	block, err := newBlock(newCipher, key)
+	if err != nil {
+		return nil, err
+	}
+	if block.BlockSize() <= 0 {
+		return nil, ErrInvalidBlockSize
+	}
+	return &blockBlock{
+		block:       block,
+		config:      newConfig(opts...),
+		keyLen:      len(key),
+		fingerprint: keyFingerprint(key),
+		newCipher:   newCipher,
+		randomIV:    true,
+	}, nil
+}
+
+// newBlock create the cipher.Block, wrap the error with context, so it can be unwrapped
+// by errors.Is or errors.As, such as aes.KeySizeError.
+func newBlock(newCipher func(key []byte) (cipher.Block, error), key []byte) (cipher.Block, error) {
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aesext: create cipher: %w", err)
+	}
+	return block, nil
+}
+
+// NewCFBCrypt new cfb mode with newCipher, key, iv and custom option.
+// cfb is a stream mode, the cipher text length equal plain text length, no padding.
+func NewCFBCrypt(key, iv []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (BlockCrypt, error) {
+	return NewBlockCrypt(key, iv, newCipher,
+		append([]Option{WithStreamCodec(cipher.NewCFBEncrypter, cipher.NewCFBDecrypter)}, opts...)...)
+}
+
 type blockBlock struct {
-	block      cipher.Block
-	iv         []byte
-	newEncrypt func(block cipher.Block, iv []byte) cipher.BlockMode
-	newDecrypt func(block cipher.Block, iv []byte) cipher.BlockMode
+	block cipher.Block
+	iv    []byte
+	config
+	// key length, 0 means unknown, such as created by NewBlockCryptWithBlock, the key is never retained.
+	keyLen int
+	// the key fingerprint, empty means unknown, see KeyFingerprinter.
+	fingerprint string
+	// the factory to rebuild the block when rotate the key, nil if created by NewBlockCryptWithBlock.
+	newCipher func(key []byte) (cipher.Block, error)
+	// generate random iv for each Encrypt and prepend it to the cipher text.
+	randomIV bool
+	// pool of block mode which can reset the iv, such as cbc, avoid allocations.
+	encPool sync.Pool
+	decPool sync.Pool
 }
 
 func (sf *blockBlock) BlockSize() int {
@@ -84,37 +437,436 @@ func (sf *blockBlock) BlockSize() int {
 
 // Encrypt encrypt
 func (sf *blockBlock) Encrypt(plainText []byte) ([]byte, error) {
-	orig := PCKSPadding(plainText, sf.block.BlockSize())
-	sf.newEncrypt(sf.block, sf.iv).CryptBlocks(orig, orig)
-	return orig, nil
+	return sf.EncryptTo(make([]byte, 0, sf.encryptedSize(len(plainText))), plainText)
+}
+
+// EncryptBatch encrypt many independent plain texts, the cipher texts share one exactly sized
+// backing buffer, and the cbc encrypter is reset per message instead of recreated,
+// random iv mode still generate an independent iv for each message.
+func (sf *blockBlock) EncryptBatch(plainTexts [][]byte) ([][]byte, error) {
+	total := 0
+	for _, plainText := range plainTexts {
+		total += sf.encryptedSize(len(plainText))
+	}
+	buf := make([]byte, 0, total)
+	cipherTexts := make([][]byte, len(plainTexts))
+	for i, plainText := range plainTexts {
+		start := len(buf)
+		var err error
+		if buf, err = sf.EncryptTo(buf, plainText); err != nil {
+			return nil, err
+		}
+		cipherTexts[i] = buf[start:len(buf):len(buf)]
+	}
+	return cipherTexts, nil
+}
+
+// encryptedSize the cipher text size of the plain text with length n
+func (sf *blockBlock) encryptedSize(n int) int {
+	size := sf.paddedSize(n)
+	if sf.prefixIV() {
+		size += sf.block.BlockSize()
+	}
+	return size
+}
+
+// paddedSize the padded size of the plain text with length n, include the WithMinPadTo minimum length.
+func (sf *blockBlock) paddedSize(n int) int {
+	if sf.stream || sf.noPadding {
+		return n
+	}
+	blockSize := sf.block.BlockSize()
+	size := n + blockSize - n%blockSize
+	if p, ok := sf.padding.(minPadTo); ok {
+		if target := (p.n + blockSize - 1) / blockSize * blockSize; size < target {
+			size = target
+		}
+	}
+	return size
+}
+
+// prefixIV whether the iv is prepended to the cipher text
+func (sf *blockBlock) prefixIV() bool {
+	return sf.randomIV || sf.ivPrefix
+}
+
+// EncryptTo encrypt, the cbc mode takes no allocations if dst has enough capacity.
+func (sf *blockBlock) EncryptTo(dst, plainText []byte) ([]byte, error) {
+	if !sf.stream && sf.noPadding && len(plainText)%sf.block.BlockSize() != 0 {
+		return nil, ErrInputNotMultipleBlocks
+	}
+	iv := sf.iv
+	switch {
+	case sf.randomIV:
+		start := len(dst)
+		dst = grow(dst, sf.block.BlockSize())
+		iv = dst[start:]
+		if _, err := io.ReadFull(sf.rand, iv); err != nil {
+			return nil, err
+		}
+	case sf.ivPrefix:
+		dst = append(dst, sf.iv...)
+	}
+	return sf.encryptTo(dst, iv, plainText)
 }
 
 // Decrypt decrypt
 func (sf *blockBlock) Decrypt(cipherText []byte) ([]byte, error) {
+	if err := sf.checkCipherSize(cipherText); err != nil {
+		return nil, err
+	}
+	return sf.DecryptTo(make([]byte, 0, len(cipherText)), cipherText)
+}
+
+// DecryptTo decrypt, the cbc mode takes no allocations if dst has enough capacity.
+func (sf *blockBlock) DecryptTo(dst, cipherText []byte) ([]byte, error) {
+	if err := sf.checkCipherSize(cipherText); err != nil {
+		return nil, err
+	}
+	if !sf.prefixIV() {
+		return sf.decryptTo(dst, sf.iv, cipherText)
+	}
+	iv, cipherText, err := sf.SplitIV(cipherText)
+	if err != nil {
+		return nil, err
+	}
+	return sf.decryptTo(dst, iv, cipherText)
+}
+
+// DecryptVerify implement PaddingVerifier
+func (sf *blockBlock) DecryptVerify(cipherText []byte) ([]byte, bool, error) {
+	if err := sf.checkCipherSize(cipherText); err != nil {
+		return nil, false, err
+	}
+	iv := sf.iv
+	if sf.prefixIV() {
+		var err error
+		if iv, cipherText, err = sf.SplitIV(cipherText); err != nil {
+			return nil, false, err
+		}
+	}
+	raw, err := sf.decryptBlocks(make([]byte, 0, len(cipherText)), iv, cipherText)
+	if err != nil {
+		return nil, false, err
+	}
+	if sf.stream || sf.noPadding {
+		return raw, true, nil
+	}
+	plainText, err := unPad(sf.padding, raw, sf.block.BlockSize())
+	if err != nil {
+		return raw, false, nil
+	}
+	return plainText, true, nil
+}
+
+// Clone clone
+func (sf *blockBlock) Clone() BlockCrypt {
+	return &blockBlock{
+		block:       sf.block,
+		iv:          append([]byte(nil), sf.iv...),
+		config:      sf.config,
+		keyLen:      sf.keyLen,
+		fingerprint: sf.fingerprint,
+		newCipher:   sf.newCipher,
+		randomIV:    sf.randomIV,
+	}
+}
+
+// SetKey rotate the key
+func (sf *blockBlock) SetKey(key []byte) error {
+	if sf.newCipher == nil {
+		return ErrNoCipherFactory
+	}
+	block, err := newBlock(sf.newCipher, key)
+	if err != nil {
+		return err
+	}
+	if block.BlockSize() != sf.block.BlockSize() {
+		return ErrInvalidBlockSize
+	}
+	sf.block, sf.keyLen, sf.fingerprint = block, len(key), keyFingerprint(key)
+	// the pooled block modes are bound to the old block
+	sf.encPool = sync.Pool{}
+	sf.decPool = sync.Pool{}
+	return nil
+}
+
+// Pad implement Padder, ISO10126 keeps 0x00 if the random source failed, see ISO10126Padding.
+func (sf *blockBlock) Pad(data []byte) []byte {
+	out := append(make([]byte, 0, len(data)+sf.block.BlockSize()), data...)
+	if sf.stream || sf.noPadding {
+		return out
+	}
+	return sf.padding.Pad(out, sf.block.BlockSize())
+}
+
+// UnPad implement Padder
+func (sf *blockBlock) UnPad(data []byte) ([]byte, error) {
+	if sf.stream || sf.noPadding {
+		return data, nil
+	}
+	return unPad(sf.padding, data, sf.block.BlockSize())
+}
+
+// Block returns the underlying cipher.Block
+func (sf *blockBlock) Block() cipher.Block {
+	return sf.block
+}
+
+// KeyFingerprint implement KeyFingerprinter
+func (sf *blockBlock) KeyFingerprint() string {
+	return sf.fingerprint
+}
+
+// SplitIV implement IVSplitter
+func (sf *blockBlock) SplitIV(blob []byte) ([]byte, []byte, error) {
 	blockSize := sf.block.BlockSize()
-	if len(cipherText) == 0 || len(cipherText)%blockSize != 0 {
+	if len(blob) < blockSize {
+		return nil, nil, ErrCipherTextTooShort
+	}
+	return blob[:blockSize:blockSize], blob[blockSize:], nil
+}
+
+// IV returns a copy of the iv, random iv mode returns nil.
+func (sf *blockBlock) IV() []byte {
+	if sf.randomIV {
+		return nil
+	}
+	return append([]byte{}, sf.iv...)
+}
+
+// SetIV set the iv, random iv mode ignore it, since each Encrypt generate a fresh one.
+func (sf *blockBlock) SetIV(iv []byte) error {
+	if err := sf.checkIV(iv); err != nil {
+		return err
+	}
+	sf.iv = append(sf.iv[:0], iv...)
+	return nil
+}
+
+// EncryptWithIV encrypt with the iv
+func (sf *blockBlock) EncryptWithIV(iv, plainText []byte) ([]byte, error) {
+	blockSize := sf.block.BlockSize()
+	if len(iv) != blockSize {
+		return nil, &IvSizeError{len(iv), blockSize}
+	}
+	if !sf.stream && sf.noPadding && len(plainText)%blockSize != 0 {
 		return nil, ErrInputNotMultipleBlocks
 	}
-	sf.newDecrypt(sf.block, sf.iv).CryptBlocks(cipherText, cipherText)
-	return PCKSUnPadding(cipherText)
+	return sf.encryptTo(make([]byte, 0, sf.paddedSize(len(plainText))), iv, plainText)
 }
 
-// PCKSPadding PKCS#5和PKCS#7 填充
-func PCKSPadding(origData []byte, blockSize int) []byte {
-	padSize := blockSize - len(origData)%blockSize
-	padText := bytes.Repeat([]byte{byte(padSize)}, padSize)
-	return append(origData, padText...)
+// DecryptWithIV decrypt with the iv
+func (sf *blockBlock) DecryptWithIV(iv, cipherText []byte) ([]byte, error) {
+	if len(iv) != sf.block.BlockSize() {
+		return nil, &IvSizeError{len(iv), sf.block.BlockSize()}
+	}
+	if err := sf.checkCipherSize(cipherText); err != nil {
+		return nil, err
+	}
+	return sf.decryptTo(make([]byte, 0, len(cipherText)), iv, cipherText)
 }
 
-// PCKSUnPadding PKCS#5和PKCS#7 解填充
-func PCKSUnPadding(origData []byte) ([]byte, error) {
-	length := len(origData)
-	if length == 0 {
-		return nil, ErrUnPaddingOutOfRange
+// Close zero the iv and drop the internal buffers, it implement io.Closer.
+// the key is not retained by blockBlock, and the cipher.Block hides the expanded key
+// internally, so it can't be zeroed.
+// using the BlockCrypt after Close is undefined.
+func (sf *blockBlock) Close() error {
+	for i := range sf.iv {
+		sf.iv[i] = 0
 	}
-	unPadSize := int(origData[length-1])
-	if unPadSize > length {
-		return nil, ErrUnPaddingOutOfRange
+	sf.encPool = sync.Pool{}
+	sf.decPool = sync.Pool{}
+	return nil
+}
+
+// encrypter return the header which should be prepend to the cipher text and
+// a block mode for encrypt, used by streaming.
+func (sf *blockBlock) encrypter() (header []byte, mode cipher.BlockMode, err error) {
+	if !sf.randomIV {
+		if sf.ivPrefix {
+			header = append([]byte{}, sf.iv...)
+		}
+		return header, sf.newEncrypt(sf.block, sf.iv), nil
+	}
+	iv := make([]byte, sf.block.BlockSize())
+	if _, err := io.ReadFull(sf.rand, iv); err != nil {
+		return nil, nil, err
+	}
+	return iv, sf.newEncrypt(sf.block, iv), nil
+}
+
+// decrypter read the header from r if it has been prepended to the cipher text,
+// and return a block mode for decrypt, used by streaming.
+func (sf *blockBlock) decrypter(r io.Reader) (cipher.BlockMode, error) {
+	if !sf.prefixIV() {
+		return sf.newDecrypt(sf.block, sf.iv), nil
 	}
-	return origData[:(length - unPadSize)], nil
+	iv := make([]byte, sf.block.BlockSize())
+	if _, err := io.ReadFull(r, iv); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrCipherTextTooShort
+		}
+		return nil, err
+	}
+	return sf.newDecrypt(sf.block, iv), nil
+}
+
+func (sf *blockBlock) encryptTo(dst, iv, plainText []byte) ([]byte, error) {
+	start := len(dst)
+	mode := getBlockMode(&sf.encPool, sf.newEncrypt, sf.block, iv)
+	defer putBlockMode(&sf.encPool, mode)
+	if sf.stream || sf.noPadding {
+		dst = grow(dst, len(plainText))
+		mode.CryptBlocks(dst[start:], plainText)
+	} else {
+		// copy to dst with spare capacity for padding, never modify the caller's plain text.
+		// the capacity is exactly rounded up to the next block, as Encrypt allocated,
+		// so it avoids append-driven reallocation.
+		blockSize := sf.block.BlockSize()
+		padSize := blockSize - len(plainText)%blockSize
+		dst = append(grow(dst, len(plainText)+padSize)[:start], plainText...)
+		padded, err := pad(sf.padding, dst[start:], blockSize)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst[:start], padded...)
+		mode.CryptBlocks(dst[start:], dst[start:])
+	}
+	return dst, nil
+}
+
+// ivSetter block mode which can reset the iv, such as cbc in the standard library.
+type ivSetter interface {
+	SetIV(iv []byte)
+}
+
+func getBlockMode(pool *sync.Pool, newMode func(block cipher.Block, iv []byte) cipher.BlockMode,
+	block cipher.Block, iv []byte) cipher.BlockMode {
+	if v := pool.Get(); v != nil {
+		mode := v.(cipher.BlockMode)
+		mode.(ivSetter).SetIV(iv)
+		return mode
+	}
+	return newMode(block, iv)
+}
+
+func putBlockMode(pool *sync.Pool, mode cipher.BlockMode) {
+	if _, ok := mode.(ivSetter); ok {
+		pool.Put(mode)
+	}
+}
+
+func (sf *blockBlock) decryptTo(dst, iv, cipherText []byte) ([]byte, error) {
+	start := len(dst)
+	dst, err := sf.decryptBlocks(dst, iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+	if sf.stream || sf.noPadding {
+		return dst, nil
+	}
+	plainText, err := unPad(sf.padding, dst[start:], sf.block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	return append(dst[:start], plainText...), nil
+}
+
+// decryptBlocks decrypt the cipher text, appends the raw output which still contains padding to dst.
+func (sf *blockBlock) decryptBlocks(dst, iv, cipherText []byte) ([]byte, error) {
+	blockSize := sf.block.BlockSize()
+	if !sf.stream && ((len(cipherText) == 0 && !sf.allowEmpty()) || len(cipherText)%blockSize != 0) {
+		return nil, ErrInputNotMultipleBlocks
+	}
+	start := len(dst)
+	dst = grow(dst, len(cipherText))
+	out := dst[start:]
+	mode := getBlockMode(&sf.decPool, sf.newDecrypt, sf.block, iv)
+	if inexactOverlap(out, cipherText) {
+		// such as random iv mode with cipherText[:0] as dst, decrypt in place, then move it.
+		mode.CryptBlocks(cipherText, cipherText)
+		copy(out, cipherText)
+	} else {
+		mode.CryptBlocks(out, cipherText)
+	}
+	putBlockMode(&sf.decPool, mode)
+	return dst, nil
+}
+
+// allowEmpty reports whether the empty cipher text is valid, that is the padding adds nothing
+// to the empty plain text, such as stream mode, WithNoPadding and the Zero padding.
+func (sf *blockBlock) allowEmpty() bool {
+	_, zero := sf.padding.(Zero)
+	return sf.stream || sf.noPadding || zero
+}
+
+func (sf *blockBlock) checkCipherSize(cipherText []byte) error {
+	if sf.maxCipherSize > 0 && len(cipherText) > sf.maxCipherSize {
+		return ErrCipherTextTooLarge
+	}
+	return nil
+}
+
+// inexactOverlap reports whether x and y share memory at any non-corresponding index.
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+	return uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
+
+// grow grows b's capacity, to guarantee space for another n bytes, return b[:len(b)+n].
+func grow(b []byte, n int) []byte {
+	if n <= cap(b)-len(b) {
+		return b[:len(b)+n]
+	}
+	nb := make([]byte, len(b)+n, 2*cap(b)+n)
+	copy(nb, b)
+	return nb
+}
+
+// streamMode adapt cipher.Stream to cipher.BlockMode
+type streamMode struct {
+	cipher.Stream
+	blockSize int
+}
+
+func streamBlockMode(newStream func(block cipher.Block, iv []byte) cipher.Stream) func(block cipher.Block, iv []byte) cipher.BlockMode {
+	return func(block cipher.Block, iv []byte) cipher.BlockMode {
+		return &streamMode{newStream(block, iv), block.BlockSize()}
+	}
+}
+
+func (sf *streamMode) BlockSize() int { return sf.blockSize }
+
+func (sf *streamMode) CryptBlocks(dst, src []byte) { sf.XORKeyStream(dst, src) }
+
+// checkIV validate the iv to be installed, return *IvSizeError if the length not equal the block size,
+// ErrWeakIV if the iv is all zero with WithStrictIV.
+func (sf *blockBlock) checkIV(iv []byte) error {
+	if len(iv) != sf.block.BlockSize() {
+		return &IvSizeError{len(iv), sf.block.BlockSize()}
+	}
+	if sf.strictIV && isZeroIV(iv) {
+		return ErrWeakIV
+	}
+	return nil
+}
+
+// isZeroIV reports whether the iv is all zero.
+func isZeroIV(iv []byte) bool {
+	for _, b := range iv {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// keyFingerprint returns the first 4 bytes of the key's sha256 in hex.
+func keyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
 }
@@ -1,10 +1,14 @@
 package aesext
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/des"
 	"crypto/sha256"
 	"errors"
+	"io"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,6 +21,16 @@ func mockErrorNewCipher([]byte) (cipher.Block, error) {
 	return nil, errors.New("mock error new cipher")
 }
 
+// mockZeroBlock misbehaving cipher.Block with zero block size
+type mockZeroBlock struct{ cipher.Block }
+
+func (mockZeroBlock) BlockSize() int { return 0 }
+
+func mockZeroBlockNewCipher(key []byte) (cipher.Block, error) {
+	block, err := aes.NewCipher(key)
+	return mockZeroBlock{block}, err
+}
+
 func TestBlockModeCipher(t *testing.T) {
 	key := []byte("secret_key")
 	salt := []byte("secret_salt")
@@ -44,12 +58,1045 @@ func TestBlockModeCipher(t *testing.T) {
 		}
 	})
 
+	t.Run("not mutate plain text", func(t *testing.T) {
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+
+		buf := make([]byte, 0, 64)
+		plainText := append(buf, "hello"...)
+		spare := buf[:cap(buf)]
+		for i := len(plainText); i < len(spare); i++ {
+			spare[i] = 0xee
+		}
+
+		cipherText1, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		cipherText2, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, cipherText1, cipherText2)
+		assert.Equal(t, []byte("hello"), plainText)
+		for _, v := range spare[len(plainText):] {
+			require.Equal(t, byte(0xee), v)
+		}
+	})
+
+	t.Run("not mutate cipher text", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		orig := append([]byte{}, cipherText...)
+
+		for i := 0; i < 2; i++ {
+			got, err := blk.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+			assert.Equal(t, orig, cipherText)
+		}
+	})
+
+	t.Run("plain text independent of cipher text", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		cbc, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		randomIV, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+		ctr, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithStreamCodec(cipher.NewCTR, cipher.NewCTR))
+		require.NoError(t, err)
+		noPadding, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+
+		for _, blk := range []BlockCrypt{cbc, randomIV, ctr, noPadding} {
+			data := plainText
+			if blk == noPadding {
+				data = plainText[:2*aes.BlockSize]
+			}
+			cipherText, err := blk.Encrypt(data)
+			require.NoError(t, err)
+			got, err := blk.Decrypt(cipherText)
+			require.NoError(t, err)
+
+			// reuse the cipher text buffer
+			for i := range cipherText {
+				cipherText[i] = 0xff
+			}
+			assert.Equal(t, data, got)
+		}
+	})
+
+	t.Run("not share caller iv", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		ivCopy := append([]byte{}, iv[:aes.BlockSize]...)
+		blk, err := NewBlockCrypt(newKey[:16], ivCopy, aes.NewCipher)
+		require.NoError(t, err)
+		want, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+
+		ivCopy[0] ^= 0xff
+		got, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		for _, opt := range []Option{
+			WithBlockCodec(cipher.NewCBCEncrypter, cipher.NewCBCDecrypter),
+			WithStreamCodec(cipher.NewCTR, cipher.NewCTR),
+		} {
+			blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, opt)
+			require.NoError(t, err)
+			want, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+
+			wg := sync.WaitGroup{}
+			errs := make(chan error, 100)
+			for i := 0; i < 100; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					cipherText, err := blk.Encrypt(plainText)
+					if err == nil && !bytes.Equal(cipherText, want) {
+						err = errors.New("cipher text mismatch")
+					}
+					if err == nil {
+						var got []byte
+						got, err = blk.Decrypt(cipherText)
+						if err == nil && !bytes.Equal(got, plainText) {
+							err = errors.New("plain text mismatch")
+						}
+					}
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				require.NoError(t, err)
+			}
+		}
+	})
+
+	t.Run("encrypt to", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		for _, opt := range []Option{
+			WithBlockCodec(cipher.NewCBCEncrypter, cipher.NewCBCDecrypter),
+			WithStreamCodec(cipher.NewCTR, cipher.NewCTR),
+		} {
+			blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, opt)
+			require.NoError(t, err)
+			want, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+
+			dst := []byte("prefix")
+			for i := 0; i < 3; i++ {
+				dst, err = blk.EncryptTo(dst[:6], plainText)
+				require.NoError(t, err)
+				assert.Equal(t, append([]byte("prefix"), want...), dst)
+			}
+		}
+
+		blk, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher, WithRand(bytes.NewReader(iv[:aes.BlockSize])))
+		require.NoError(t, err)
+		dst, err := blk.EncryptTo([]byte("prefix"), plainText)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("prefix"), dst[:6])
+		got, err := blk.Decrypt(dst[6:])
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+		_, err = blk.EncryptTo(nil, plainText)
+		require.Error(t, err)
+	})
+
+	t.Run("decrypt to", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		cbc, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		ctr, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithStreamCodec(cipher.NewCTR, cipher.NewCTR))
+		require.NoError(t, err)
+		zero, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithPadding(Zero{}))
+		require.NoError(t, err)
+		randomIV, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+
+		for _, blk := range []BlockCrypt{cbc, ctr, zero, randomIV} {
+			cipherText, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+
+			dst := []byte("prefix")
+			for i := 0; i < 3; i++ {
+				dst, err = blk.DecryptTo(dst[:6], cipherText)
+				require.NoError(t, err)
+				assert.Equal(t, append([]byte("prefix"), plainText...), dst)
+			}
+
+			// in place
+			got, err := blk.DecryptTo(cipherText[:0], cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		_, err = cbc.DecryptTo(nil, plainText[:5])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+		_, err = randomIV.DecryptTo(nil, plainText[:5])
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		_, err = blk.Encrypt([]byte("hello"))
+		require.NoError(t, err)
+
+		closer, ok := blk.(io.Closer)
+		require.True(t, ok)
+		require.NoError(t, closer.Close())
+		assert.Equal(t, make([]byte, aes.BlockSize), blk.(*blockBlock).iv)
+		// the caller's iv is untouched
+		assert.NotEqual(t, make([]byte, aes.BlockSize), iv[:aes.BlockSize])
+	})
+
+	t.Run("iv accessor", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+
+		accessor, ok := blk.(IVAccessor)
+		require.True(t, ok)
+		got := accessor.IV()
+		assert.Equal(t, iv[:aes.BlockSize], got)
+		// returns a copy
+		got[0] ^= 0xff
+		assert.Equal(t, iv[:aes.BlockSize], accessor.IV())
+
+		newIV := []byte("new_iv_16_bytes_")
+		require.NoError(t, accessor.SetIV(newIV))
+		assert.Equal(t, newIV, accessor.IV())
+		rotated, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.NotEqual(t, cipherText, rotated)
+
+		want, err := NewBlockCrypt(newKey[:16], newIV, aes.NewCipher)
+		require.NoError(t, err)
+		wantCipherText, err := want.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, wantCipherText, rotated)
+
+		require.ErrorIs(t, accessor.SetIV(newIV[:8]), ErrInvalidIvSize)
+		assert.Equal(t, newIV, accessor.IV())
+
+		randomIV, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+		assert.Nil(t, randomIV.(IVAccessor).IV())
+	})
+
+	t.Run("per call iv", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		messageIV := []byte("message_iv_16byt")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		accessor := blk.(IVAccessor)
+
+		cipherText, err := accessor.EncryptWithIV(messageIV, plainText)
+		require.NoError(t, err)
+		want, err := NewBlockCrypt(newKey[:16], messageIV, aes.NewCipher)
+		require.NoError(t, err)
+		wantCipherText, err := want.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, wantCipherText, cipherText)
+
+		got, err := accessor.DecryptWithIV(messageIV, cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		// the stored iv is untouched
+		assert.Equal(t, iv[:aes.BlockSize], accessor.IV())
+		got, err = blk.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.NotEqual(t, plainText, got)
+
+		_, err = accessor.EncryptWithIV(messageIV[:8], plainText)
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+		_, err = accessor.DecryptWithIV(messageIV[:8], cipherText)
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+
+		noPadding, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+		_, err = noPadding.(IVAccessor).EncryptWithIV(messageIV, plainText)
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+
+	t.Run("set key", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		otherKey := sha256.Sum256([]byte("other_key"))
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		oldCipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+
+		setter, ok := blk.(KeySetter)
+		require.True(t, ok)
+		require.NoError(t, setter.SetKey(otherKey[:32]))
+		newCipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.NotEqual(t, oldCipherText, newCipherText)
+
+		want, err := NewBlockCrypt(otherKey[:32], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		wantCipherText, err := want.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, wantCipherText, newCipherText)
+
+		got, err := blk.Decrypt(newCipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+		got, err = blk.Decrypt(oldCipherText)
+		if err == nil {
+			assert.NotEqual(t, plainText, got)
+		}
+
+		// bad key size keeps the current key
+		var keySizeError aes.KeySizeError
+		err = setter.SetKey(otherKey[:20])
+		require.True(t, errors.As(err, &keySizeError))
+		got, err = blk.Decrypt(newCipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		// the factory returns a different block size
+		mixed, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], func(key []byte) (cipher.Block, error) {
+			if len(key) == 8 {
+				return des.NewCipher(key)
+			}
+			return aes.NewCipher(key)
+		})
+		require.NoError(t, err)
+		require.Equal(t, ErrInvalidBlockSize, mixed.(KeySetter).SetKey(otherKey[:8]))
+
+		block, err := aes.NewCipher(newKey[:16])
+		require.NoError(t, err)
+		withBlock, err := NewBlockCryptWithBlock(block, iv[:aes.BlockSize])
+		require.NoError(t, err)
+		require.Equal(t, ErrNoCipherFactory, withBlock.(KeySetter).SetKey(otherKey[:16]))
+	})
+
+	t.Run("block accessor", func(t *testing.T) {
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		accessor, ok := blk.(BlockAccessor)
+		require.True(t, ok)
+		block := accessor.Block()
+		assert.Same(t, blk.(*blockBlock).block, block)
+
+		// build a custom mode with the block
+		plainText := []byte("helloworld,this is golang language. welcome")
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		want := PCKSPadding(append([]byte{}, plainText...), aes.BlockSize)
+		cipher.NewCBCEncrypter(block, iv[:aes.BlockSize]).CryptBlocks(want, want)
+		assert.Equal(t, want, cipherText)
+	})
+
+	t.Run("padder", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithPadding(X923{}))
+		require.NoError(t, err)
+		padder, ok := blk.(Padder)
+		require.True(t, ok)
+
+		data := append([]byte{}, plainText...)
+		padded := padder.Pad(data)
+		assert.Equal(t, plainText, data)
+		assert.Equal(t, X923Padding(append([]byte{}, plainText...), aes.BlockSize), padded)
+		got, err := padder.UnPad(padded)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		// pad manually, then encrypt without padding
+		noPadding, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+		cipherText, err := noPadding.Encrypt(padded)
+		require.NoError(t, err)
+		want, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, want, cipherText)
+
+		// no padding
+		assert.Equal(t, plainText, noPadding.(Padder).Pad(plainText))
+		got, err = noPadding.(Padder).UnPad(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, err = padder.UnPad(plainText)
+		require.Error(t, err)
+	})
+
+	t.Run("decrypt verify", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		verifier, ok := blk.(PaddingVerifier)
+		require.True(t, ok)
+
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		got, valid, err := verifier.DecryptVerify(cipherText)
+		require.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, plainText, got)
+
+		// wrong key, decrypted but bad padding
+		wrongKey, err := NewBlockCrypt(newKey[16:], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		got, valid, err = wrongKey.(PaddingVerifier).DecryptVerify(cipherText)
+		require.NoError(t, err)
+		assert.False(t, valid)
+		assert.Equal(t, len(cipherText), len(got))
+		_, err = wrongKey.Decrypt(cipherText)
+		require.Error(t, err)
+
+		// structurally invalid
+		_, valid, err = verifier.DecryptVerify(cipherText[:len(cipherText)-1])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+		assert.False(t, valid)
+
+		randomIV, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err = randomIV.Encrypt(plainText)
+		require.NoError(t, err)
+		got, valid, err = randomIV.(PaddingVerifier).DecryptVerify(cipherText)
+		require.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, plainText, got)
+		_, _, err = randomIV.(PaddingVerifier).DecryptVerify(cipherText[:aes.BlockSize-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+
+		noPadding, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+		got, valid, err = noPadding.(PaddingVerifier).DecryptVerify(cipherText[:aes.BlockSize])
+		require.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, aes.BlockSize, len(got))
+
+		limited, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithMaxCipherSize(16))
+		require.NoError(t, err)
+		_, _, err = limited.(PaddingVerifier).DecryptVerify(cipherText)
+		require.Equal(t, ErrCipherTextTooLarge, err)
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+
+		cloned := blk.Clone()
+		assert.Same(t, blk.(*blockBlock).block, cloned.(*blockBlock).block)
+		got, err := cloned.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, cipherText, got)
+
+		// deep copy iv
+		require.NoError(t, blk.(io.Closer).Close())
+		got, err = cloned.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("ctr stream", func(t *testing.T) {
+		plainText := []byte("hello")
+		for _, keySize := range aesKeySizes {
+			blk, err := NewBlockCrypt(newKey[:keySize], iv[:aes.BlockSize], aes.NewCipher,
+				WithStreamCodec(cipher.NewCTR, cipher.NewCTR))
+			require.NoError(t, err)
+
+			assert.Equal(t, aes.BlockSize, blk.BlockSize())
+
+			cipherText, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+			require.Len(t, cipherText, len(plainText))
+			want, err := blk.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, want, plainText)
+			assert.Equal(t, []byte("hello"), plainText)
+		}
+	})
+
+	t.Run("cfb", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		for _, keySize := range aesKeySizes {
+			blk, err := NewCFBCrypt(newKey[:keySize], iv[:aes.BlockSize], aes.NewCipher)
+			require.NoError(t, err)
+
+			cipherText, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+			require.Len(t, cipherText, len(plainText))
+
+			want := make([]byte, len(plainText))
+			block, err := aes.NewCipher(newKey[:keySize])
+			require.NoError(t, err)
+			cipher.NewCFBEncrypter(block, iv[:aes.BlockSize]).XORKeyStream(want, plainText)
+			assert.Equal(t, want, cipherText)
+
+			got, err := blk.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+		_, err := NewCFBCrypt(newKey[:16], iv[:8], aes.NewCipher)
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+	})
+
+	t.Run("ofb", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		for _, keySize := range aesKeySizes {
+			blk, err := NewOFBCrypt(newKey[:keySize], iv[:aes.BlockSize], aes.NewCipher)
+			require.NoError(t, err)
+
+			cipherText, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+			require.Len(t, cipherText, len(plainText))
+
+			want := make([]byte, len(plainText))
+			block, err := aes.NewCipher(newKey[:keySize])
+			require.NoError(t, err)
+			cipher.NewOFB(block, iv[:aes.BlockSize]).XORKeyStream(want, plainText)
+			assert.Equal(t, want, cipherText)
+
+			got, err := blk.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+	})
+
+	t.Run("stream mode with block codec", func(t *testing.T) {
+		plainText := []byte("hello")
+		ofb := func(block cipher.Block, iv []byte) cipher.BlockMode {
+			return &streamMode{cipher.NewOFB(block, iv), block.BlockSize()}
+		}
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher,
+			WithBlockCodec(ofb, ofb), WithStreamMode())
+		require.NoError(t, err)
+
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		require.Len(t, cipherText, len(plainText))
+		got, err := blk.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("ctr stream mode with block codec", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		ctr := func(block cipher.Block, iv []byte) cipher.BlockMode {
+			return &streamMode{cipher.NewCTR(block, iv), block.BlockSize()}
+		}
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher,
+			WithBlockCodec(ctr, ctr), WithStreamMode())
+		require.NoError(t, err)
+
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		block, err := aes.NewCipher(newKey[:16])
+		require.NoError(t, err)
+		want := make([]byte, len(plainText))
+		cipher.NewCTR(block, iv[:aes.BlockSize]).XORKeyStream(want, plainText)
+		assert.Equal(t, want, cipherText)
+
+		got, err := blk.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("no padding", func(t *testing.T) {
+		plainText := []byte("aligned 32 bytes binary format..")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		require.Len(t, cipherText, len(plainText))
+
+		padded, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		want, err := padded.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, want[:len(plainText)], cipherText)
+
+		got, err := blk.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+		// the raw block output unchanged
+		got, err = blk.Decrypt(want)
+		require.NoError(t, err)
+		assert.Equal(t, append(append([]byte{}, plainText...), bytes.Repeat([]byte{aes.BlockSize}, aes.BlockSize)...), got)
+
+		_, err = blk.Encrypt(plainText[:5])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+		_, err = blk.Decrypt(cipherText[:5])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+
+	t.Run("encrypt batch", func(t *testing.T) {
+		plainTexts := [][]byte{
+			[]byte("hello"),
+			{},
+			[]byte("helloworld,this is golang language. welcome"),
+			bytes.Repeat([]byte{0x01}, 2*aes.BlockSize),
+		}
+		cbc, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		ctr, err := NewCTRCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		for name, blk := range map[string]BlockCrypt{"cbc": cbc, "ctr": ctr} {
+			cipherTexts, err := blk.(BatchEncrypter).EncryptBatch(plainTexts)
+			require.NoError(t, err, name)
+			require.Len(t, cipherTexts, len(plainTexts), name)
+			for i, plainText := range plainTexts {
+				want, err := blk.Encrypt(plainText)
+				require.NoError(t, err, name)
+				assert.Equal(t, want, cipherTexts[i], name)
+			}
+			// independent, append one not overwrite the next
+			_ = append(cipherTexts[0], 0xff)
+			want, err := blk.Encrypt(plainTexts[1])
+			require.NoError(t, err)
+			assert.Equal(t, want, cipherTexts[1], name)
+		}
+
+		randomIV, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+		cipherTexts, err := randomIV.(BatchEncrypter).EncryptBatch([][]byte{plainTexts[2], plainTexts[2]})
+		require.NoError(t, err)
+		assert.NotEqual(t, cipherTexts[0][:aes.BlockSize], cipherTexts[1][:aes.BlockSize])
+		for _, cipherText := range cipherTexts {
+			got, err := randomIV.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainTexts[2], got)
+		}
+
+		noPadding, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+		_, err = noPadding.(BatchEncrypter).EncryptBatch(plainTexts)
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+
+	t.Run("empty plain text", func(t *testing.T) {
+		cbc, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		ctr, err := NewCTRCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		cfb, err := NewCFBCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		ofb, err := NewOFBCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		noPadding, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+		zero, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithPadding(Zero{}))
+		require.NoError(t, err)
+		randomIV, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+		etm, err := NewEncryptThenMAC(newKey[:16], newKey[:], aes.NewCipher)
+		require.NoError(t, err)
+		openssl, err := NewOpenSSLCrypt([]byte("secret"), 32, aes.NewCipher)
+		require.NoError(t, err)
+
+		for name, blk := range map[string]BlockCrypt{
+			"cbc":        cbc,
+			"ctr":        ctr,
+			"cfb":        cfb,
+			"ofb":        ofb,
+			"no padding": noPadding,
+			"zero":       zero,
+			"random iv":  randomIV,
+			"etm":        etm,
+			"openssl":    openssl,
+		} {
+			for _, plainText := range [][]byte{nil, {}} {
+				cipherText, err := blk.Encrypt(plainText)
+				require.NoError(t, err, name)
+				got, err := blk.Decrypt(cipherText)
+				require.NoError(t, err, name)
+				assert.NotNil(t, got, name)
+				assert.Empty(t, got, name)
+			}
+		}
+	})
+
+	t.Run("max cipher size", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithMaxCipherSize(48))
+		require.NoError(t, err)
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		require.Len(t, cipherText, 48)
+
+		got, err := blk.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		large := append(append([]byte{}, cipherText...), cipherText[:aes.BlockSize]...)
+		_, err = blk.Decrypt(large)
+		require.Equal(t, ErrCipherTextTooLarge, err)
+		_, err = blk.DecryptTo(nil, large)
+		require.Equal(t, ErrCipherTextTooLarge, err)
+		_, err = blk.(IVAccessor).DecryptWithIV(iv[:aes.BlockSize], large)
+		require.Equal(t, ErrCipherTextTooLarge, err)
+
+		// default unlimited
+		unlimited, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		_, err = unlimited.Decrypt(large)
+		require.NotEqual(t, ErrCipherTextTooLarge, err)
+	})
+
+	t.Run("random iv", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		for _, keySize := range aesKeySizes {
+			blk, err := NewBlockCryptRandomIV(newKey[:keySize], aes.NewCipher)
+			require.NoError(t, err)
+
+			cipherText1, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+			cipherText2, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+			assert.NotEqual(t, cipherText1, cipherText2)
+
+			for _, cipherText := range [][]byte{cipherText1, cipherText2} {
+				got, err := blk.Decrypt(cipherText)
+				require.NoError(t, err)
+				assert.Equal(t, plainText, got)
+			}
+		}
+	})
+
+	t.Run("random iv with rand", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher,
+			WithRand(bytes.NewReader(iv[:aes.BlockSize])))
+		require.NoError(t, err)
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+
+		fixed, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		want, err := fixed.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, iv[:aes.BlockSize], cipherText[:aes.BlockSize])
+		assert.Equal(t, want, cipherText[aes.BlockSize:])
+
+		// random source exhausted
+		_, err = blk.Encrypt(plainText)
+		require.Error(t, err)
+		_, err = blk.Decrypt(cipherText[:aes.BlockSize-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = blk.Decrypt(cipherText[:aes.BlockSize])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+
+	t.Run("iv prefix", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithIVPrefix())
+		require.NoError(t, err)
+		fixed, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		want, err := fixed.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, iv[:aes.BlockSize], cipherText[:aes.BlockSize])
+		assert.Equal(t, want, cipherText[aes.BlockSize:])
+
+		// regenerate the iv per message, the receiver need not know it
+		newIV := bytes.Repeat([]byte{0x5a}, aes.BlockSize)
+		sender := blk.Clone()
+		require.NoError(t, sender.(IVAccessor).SetIV(newIV))
+		cipherText2, err := sender.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, newIV, cipherText2[:aes.BlockSize])
+
+		for _, ct := range [][]byte{cipherText, cipherText2} {
+			got, err := blk.Decrypt(ct)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		_, err = blk.Decrypt(cipherText[:aes.BlockSize-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = blk.Decrypt(cipherText[:aes.BlockSize])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+
+	t.Run("key fingerprint", func(t *testing.T) {
+		key := []byte("0123456789abcdef")
+		blk, err := NewBlockCrypt(key, iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		// printf '0123456789abcdef' | sha256sum
+		assert.Equal(t, "9f9f5111", blk.(KeyFingerprinter).KeyFingerprint())
+		assert.Equal(t, "9f9f5111", blk.Clone().(KeyFingerprinter).KeyFingerprint())
+
+		randomIV, err := NewBlockCryptRandomIV(key, aes.NewCipher)
+		require.NoError(t, err)
+		assert.Equal(t, "9f9f5111", randomIV.(KeyFingerprinter).KeyFingerprint())
+		ctr, err := NewCTRCrypt(key, iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		assert.Equal(t, "9f9f5111", ctr.(KeyFingerprinter).KeyFingerprint())
+
+		require.NoError(t, blk.(KeySetter).SetKey([]byte("fedcba9876543210")))
+		assert.Equal(t, "3465f6e6", blk.(KeyFingerprinter).KeyFingerprint())
+		require.Error(t, blk.(KeySetter).SetKey(key[:10]))
+		assert.Equal(t, "3465f6e6", blk.(KeyFingerprinter).KeyFingerprint())
+
+		block, err := aes.NewCipher(key)
+		require.NoError(t, err)
+		withBlock, err := NewBlockCryptWithBlock(block, iv[:aes.BlockSize])
+		require.NoError(t, err)
+		assert.Empty(t, withBlock.(KeyFingerprinter).KeyFingerprint())
+	})
+
+	t.Run("split iv", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		blk, err := NewBlockCryptRandomIV(newKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+		fixed, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText, err := blk.Encrypt(plainText)
+		require.NoError(t, err)
+		for _, bc := range []BlockCrypt{blk, fixed} {
+			gotIV, rest, err := bc.(IVSplitter).SplitIV(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, cipherText[:aes.BlockSize], gotIV)
+			assert.Equal(t, cipherText[aes.BlockSize:], rest)
+			assert.Equal(t, aes.BlockSize, cap(gotIV))
+		}
+		gotIV, rest, err := blk.(IVSplitter).SplitIV(cipherText)
+		require.NoError(t, err)
+		got, err := fixed.(IVAccessor).DecryptWithIV(gotIV, rest)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		gotIV, rest, err = blk.(IVSplitter).SplitIV(cipherText[:aes.BlockSize])
+		require.NoError(t, err)
+		assert.Len(t, gotIV, aes.BlockSize)
+		assert.Empty(t, rest)
+		_, _, err = blk.(IVSplitter).SplitIV(cipherText[:aes.BlockSize-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+
+	t.Run("with block", func(t *testing.T) {
+		plainText := []byte("helloworld,this is golang language. welcome")
+		block, err := aes.NewCipher(newKey[:16])
+		require.NoError(t, err)
+
+		blk1, err := NewBlockCryptWithBlock(block, iv[:aes.BlockSize])
+		require.NoError(t, err)
+		blk2, err := NewBlockCryptWithBlock(block, []byte("another_iv_16byt"))
+		require.NoError(t, err)
+		assert.Same(t, block, blk1.(BlockAccessor).Block())
+
+		want, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := blk1.Encrypt(plainText)
+		require.NoError(t, err)
+		wantCipherText, err := want.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, wantCipherText, cipherText)
+
+		cipherText2, err := blk2.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.NotEqual(t, cipherText, cipherText2)
+		got, err := blk2.Decrypt(cipherText2)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, err = NewBlockCryptWithBlock(block, iv[:8])
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+	})
+
+	t.Run("strict iv", func(t *testing.T) {
+		zeroIV := make([]byte, aes.BlockSize)
+		_, err := NewBlockCrypt(newKey[:16], zeroIV, aes.NewCipher)
+		require.NoError(t, err)
+		_, err = NewBlockCrypt(newKey[:16], zeroIV, aes.NewCipher, WithStrictIV())
+		require.Equal(t, ErrWeakIV, err)
+		block, err := aes.NewCipher(newKey[:16])
+		require.NoError(t, err)
+		_, err = NewBlockCryptWithBlock(block, zeroIV, WithStrictIV())
+		require.Equal(t, ErrWeakIV, err)
+		_, err = NewBlockCryptRandomIV(newKey[:16], aes.NewCipher, WithStrictIV())
+		require.NoError(t, err)
+
+		bc, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher, WithStrictIV())
+		require.NoError(t, err)
+		ivAccessor := bc.(IVAccessor)
+		require.Equal(t, ErrWeakIV, ivAccessor.SetIV(zeroIV))
+		assert.Equal(t, iv[:aes.BlockSize], ivAccessor.IV())
+		require.NoError(t, ivAccessor.SetIV(iv[1:aes.BlockSize+1]))
+	})
+
 	t.Run("invalid iv length", func(t *testing.T) {
 		_, err := NewBlockCrypt(newKey[:16], []byte{}, aes.NewCipher)
 		require.Error(t, err)
+		_, err = NewBlockCrypt(newKey[:16], iv[:8], aes.NewCipher)
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+		require.EqualError(t, err, "iv length 8 must equal block size 16")
+		var ivSizeError *IvSizeError
+		require.True(t, errors.As(err, &ivSizeError))
+		assert.Equal(t, IvSizeError{8, aes.BlockSize}, *ivSizeError)
+
+		bc, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		_, err = bc.(IVAccessor).EncryptWithIV(iv[:24], []byte("hello"))
+		require.EqualError(t, err, "iv length 24 must equal block size 16")
+		_, err = bc.(IVAccessor).DecryptWithIV(iv[:1], make([]byte, aes.BlockSize))
+		require.EqualError(t, err, "iv length 1 must equal block size 16")
+	})
+	t.Run("invalid key length", func(t *testing.T) {
+		_, err := NewBlockCrypt(newKey[:20], iv[:aes.BlockSize], aes.NewCipher)
+		require.EqualError(t, err, "aesext: create cipher: crypto/aes: invalid key size 20")
+		var keySizeError aes.KeySizeError
+		require.True(t, errors.As(err, &keySizeError))
+		assert.Equal(t, aes.KeySizeError(20), keySizeError)
+	})
+	t.Run("invalid block size", func(t *testing.T) {
+		_, err := NewBlockCrypt(newKey[:16], nil, mockZeroBlockNewCipher)
+		require.Equal(t, ErrInvalidBlockSize, err)
+		_, err = NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], mockZeroBlockNewCipher)
+		require.Equal(t, ErrInvalidBlockSize, err)
+		_, err = NewBlockCryptRandomIV(newKey[:16], mockZeroBlockNewCipher)
+		require.Equal(t, ErrInvalidBlockSize, err)
 	})
 	t.Run("invalid cipher", func(t *testing.T) {
 		_, err := NewBlockCrypt(newKey[:16], iv[:aes.BlockSize], mockErrorNewCipher)
 		require.Error(t, err)
+		_, err = NewBlockCryptRandomIV(newKey[:16], mockErrorNewCipher)
+		require.Error(t, err)
+	})
+}
+
+func BenchmarkEncryptTo(b *testing.B) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	blk, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(b, err)
+
+	plainText := make([]byte, 256)
+	dst := make([]byte, 0, len(plainText)+aes.BlockSize)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(plainText)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst, _ = blk.EncryptTo(dst[:0], plainText)
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	blk, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(b, err)
+
+	plainText := make([]byte, 256)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(plainText)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = blk.Encrypt(plainText)
+	}
+}
+
+func BenchmarkDecryptTo(b *testing.B) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	blk, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(b, err)
+
+	cipherText, err := blk.Encrypt(make([]byte, 256))
+	require.NoError(b, err)
+	dst := make([]byte, 0, len(cipherText))
+	b.ReportAllocs()
+	b.SetBytes(int64(len(cipherText)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst, _ = blk.DecryptTo(dst[:0], cipherText)
+	}
+}
+
+func BenchmarkEncryptPayload(b *testing.B) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	blk, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(b, err)
+
+	for _, bm := range []struct {
+		name string
+		size int
+	}{
+		{"1KB", 1 << 10},
+		{"1MB", 1 << 20},
+		{"100MB", 100 << 20},
+	} {
+		// not block aligned, the padding must not reallocate
+		plainText := make([]byte, bm.size+3)
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(plainText)))
+			for i := 0; i < b.N; i++ {
+				_, _ = blk.Encrypt(plainText)
+			}
+		})
+	}
+}
+
+func BenchmarkDecryptPayload(b *testing.B) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	blk, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(b, err)
+
+	for _, bm := range []struct {
+		name string
+		size int
+	}{
+		{"1KB", 1 << 10},
+		{"1MB", 1 << 20},
+		{"100MB", 100 << 20},
+	} {
+		cipherText, err := blk.Encrypt(make([]byte, bm.size+3))
+		require.NoError(b, err)
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(cipherText)))
+			for i := 0; i < b.N; i++ {
+				_, _ = blk.Decrypt(cipherText)
+			}
+		})
+	}
+}
+
+func BenchmarkEncryptBatch(b *testing.B) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	blk, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(b, err)
+
+	plainTexts := make([][]byte, 1000)
+	for i := range plainTexts {
+		plainTexts[i] = make([]byte, 64)
+	}
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = blk.(BatchEncrypter).EncryptBatch(plainTexts)
+		}
+	})
+	b.Run("loop", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cipherTexts := make([][]byte, len(plainTexts))
+			for j, plainText := range plainTexts {
+				cipherTexts[j], _ = blk.Encrypt(plainText)
+			}
+		}
 	})
 }
@@ -0,0 +1,129 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"errors"
+
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/twofish"
+)
+
+// error defined
+var (
+	ErrInvalidAES128KeySize = errors.New("aes-128 key length must be 16 bytes")
+	ErrInvalidAES192KeySize = errors.New("aes-192 key length must be 24 bytes")
+	ErrInvalidAES256KeySize = errors.New("aes-256 key length must be 32 bytes")
+
+	ErrInvalidDESKeySize       = errors.New("des key length must be 8 bytes")
+	ErrInvalidTripleDESKeySize = errors.New("3des key length must be 24 bytes")
+	ErrInvalidBlowfishKeySize  = errors.New("blowfish key length must be between 1 and 56 bytes")
+	ErrInvalidTwofishKeySize   = errors.New("twofish key length must be 16, 24 or 32 bytes")
+)
+
+// NewAESCBC new aes cbc mode with key, 16 bytes iv and custom option,
+// the aes variant is picked by the key length, 16 bytes for aes-128, 24 bytes for aes-192
+// and 32 bytes for aes-256, return ErrInvalidAESKeySize for any other length.
+func NewAESCBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if _, err := checkAESKey(key); err != nil {
+		return nil, err
+	}
+	return NewBlockCrypt(key, iv, aes.NewCipher, opts...)
+}
+
+// NewAES128CBC new aes-128 cbc mode with 16 bytes key, 16 bytes iv and custom option,
+// return ErrInvalidAES128KeySize if the key is not 16 bytes.
+func NewAES128CBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if len(key) != 16 {
+		return nil, ErrInvalidAES128KeySize
+	}
+	return NewBlockCrypt(key, iv, aes.NewCipher, opts...)
+}
+
+// NewAES192CBC new aes-192 cbc mode with 24 bytes key, 16 bytes iv and custom option,
+// return ErrInvalidAES192KeySize if the key is not 24 bytes.
+func NewAES192CBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if len(key) != 24 {
+		return nil, ErrInvalidAES192KeySize
+	}
+	return NewBlockCrypt(key, iv, aes.NewCipher, opts...)
+}
+
+// NewAES256CBC new aes-256 cbc mode with 32 bytes key, 16 bytes iv and custom option,
+// return ErrInvalidAES256KeySize if the key is not 32 bytes.
+func NewAES256CBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidAES256KeySize
+	}
+	return NewBlockCrypt(key, iv, aes.NewCipher, opts...)
+}
+
+// EncryptCBC encrypt plain text with aes cbc mode and pkcs7 padding, the key is 16, 24 or 32 bytes,
+// the iv is 16 bytes, it creates the crypt each call, see NewAESCBC.
+// NOTE: the key schedule is computed every call, so it is much slower than reusing a BlockCrypt,
+// use it for one-off operations only.
+func EncryptCBC(key, iv, plainText []byte) ([]byte, error) {
+	bc, err := NewAESCBC(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return bc.Encrypt(plainText)
+}
+
+// DecryptCBC decrypt cipher text encrypted by EncryptCBC, see EncryptCBC.
+func DecryptCBC(key, iv, cipherText []byte) ([]byte, error) {
+	bc, err := NewAESCBC(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return bc.Decrypt(cipherText)
+}
+
+// NewDESCBC new des cbc mode with 8 bytes key, 8 bytes iv and custom option,
+// return ErrInvalidDESKeySize if the key is not 8 bytes.
+// NOTE: des is broken, only use it for interoperating with legacy system.
+func NewDESCBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if len(key) != 8 {
+		return nil, ErrInvalidDESKeySize
+	}
+	return NewBlockCrypt(key, iv, des.NewCipher, opts...)
+}
+
+// NewTripleDESCBC new 3des(TDEA) cbc mode with 24 bytes key, 8 bytes iv and custom option,
+// return ErrInvalidTripleDESKeySize if the key is not 24 bytes.
+// NOTE: only use it for interoperating with legacy system.
+func NewTripleDESCBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if len(key) != 24 {
+		return nil, ErrInvalidTripleDESKeySize
+	}
+	return NewBlockCrypt(key, iv, des.NewTripleDESCipher, opts...)
+}
+
+// NewBlowfishCBC new blowfish cbc mode with key, 8 bytes iv and custom option,
+// the key length is variable, between 1 and 56 bytes, return ErrInvalidBlowfishKeySize for any other length.
+func NewBlowfishCBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	if len(key) < 1 || len(key) > 56 {
+		return nil, ErrInvalidBlowfishKeySize
+	}
+	return NewBlockCrypt(key, iv, func(key []byte) (cipher.Block, error) {
+		return blowfish.NewCipher(key)
+	}, opts...)
+}
+
+// NewTwofishCBC new twofish cbc mode with 16, 24 or 32 bytes key, 16 bytes iv and custom option,
+// return ErrInvalidTwofishKeySize for any other key length.
+func NewTwofishCBC(key, iv []byte, opts ...Option) (BlockCrypt, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidTwofishKeySize
+	}
+	return NewBlockCrypt(key, iv, func(key []byte) (cipher.Block, error) {
+		return twofish.NewCipher(key)
+	}, opts...)
+}
@@ -0,0 +1,204 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/twofish"
+)
+
+func TestAESCBC(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+	key := []byte("32_bytes_key_for_aes_256_cbc_ok!")
+	iv := key[:aes.BlockSize]
+
+	for _, keySize := range aesKeySizes {
+		bc, err := NewAESCBC(key[:keySize], iv)
+		require.NoError(t, err)
+		assert.Equal(t, aes.BlockSize, bc.BlockSize())
+
+		want, err := NewBlockCrypt(key[:keySize], iv, aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+		wantCipherText, err := want.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, wantCipherText, cipherText)
+
+		got, err := bc.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	}
+
+	for _, keySize := range []int{0, 8, 20, 33} {
+		_, err := NewAESCBC(make([]byte, keySize), iv)
+		require.Equal(t, ErrInvalidAESKeySize, err)
+	}
+	_, err := NewAESCBC(key[:16], iv[:8])
+	require.ErrorIs(t, err, ErrInvalidIvSize)
+}
+
+func TestAESCBCFixedKeySize(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+	key := []byte("32_bytes_key_for_aes_256_cbc_ok!")
+	iv := key[:aes.BlockSize]
+
+	tests := []struct {
+		name    string
+		keySize int
+		newCBC  func(key, iv []byte, opts ...Option) (BlockCrypt, error)
+		wantErr error
+	}{
+		{"aes-128", 16, NewAES128CBC, ErrInvalidAES128KeySize},
+		{"aes-192", 24, NewAES192CBC, ErrInvalidAES192KeySize},
+		{"aes-256", 32, NewAES256CBC, ErrInvalidAES256KeySize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc, err := tt.newCBC(key[:tt.keySize], iv)
+			require.NoError(t, err)
+			want, err := NewAESCBC(key[:tt.keySize], iv)
+			require.NoError(t, err)
+
+			cipherText, err := bc.Encrypt(plainText)
+			require.NoError(t, err)
+			wantCipherText, err := want.Encrypt(plainText)
+			require.NoError(t, err)
+			assert.Equal(t, wantCipherText, cipherText)
+
+			for _, keySize := range aesKeySizes {
+				if keySize != tt.keySize {
+					_, err = tt.newCBC(key[:keySize], iv)
+					require.Equal(t, tt.wantErr, err)
+				}
+			}
+			_, err = tt.newCBC(key[:tt.keySize], iv[:8])
+			require.ErrorIs(t, err, ErrInvalidIvSize)
+		})
+	}
+}
+
+func TestEncryptDecryptCBC(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+
+	// printf 'helloworld' | openssl enc -aes-128-cbc -K 30313233343536373839616263646566 -iv 66656463626139383736353433323130
+	want, err := hex.DecodeString("b4f54d61b5b6f02465c97d862064e8c7")
+	require.NoError(t, err)
+	cipherText, err := EncryptCBC(key, iv, []byte("helloworld"))
+	require.NoError(t, err)
+	assert.Equal(t, want, cipherText)
+
+	got, err := DecryptCBC(key, iv, cipherText)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("helloworld"), got)
+
+	_, err = EncryptCBC(key[:10], iv, []byte("helloworld"))
+	require.Equal(t, ErrInvalidAESKeySize, err)
+	_, err = DecryptCBC(key[:10], iv, cipherText)
+	require.Equal(t, ErrInvalidAESKeySize, err)
+	_, err = EncryptCBC(key, iv[:8], []byte("helloworld"))
+	require.ErrorIs(t, err, ErrInvalidIvSize)
+	_, err = DecryptCBC(key, iv, cipherText[:15])
+	require.Error(t, err)
+}
+
+func TestDESCBC(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+	iv := []byte("8bytesiv")
+
+	t.Run("des", func(t *testing.T) {
+		bc, err := NewDESCBC([]byte("8bytekey"), iv)
+		require.NoError(t, err)
+		assert.Equal(t, des.BlockSize, bc.BlockSize())
+
+		want, err := NewBlockCrypt([]byte("8bytekey"), iv, des.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+		wantCipherText, err := want.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, wantCipherText, cipherText)
+
+		got, err := bc.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("3des", func(t *testing.T) {
+		bc, err := NewTripleDESCBC([]byte("24_bytes_key_for_3des_ok"), iv)
+		require.NoError(t, err)
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+		got, err := bc.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("invalid key size", func(t *testing.T) {
+		for _, keySize := range []int{0, 7, 16, 24} {
+			_, err := NewDESCBC(make([]byte, keySize), iv)
+			require.Equal(t, ErrInvalidDESKeySize, err)
+		}
+		for _, keySize := range []int{0, 8, 16, 32} {
+			_, err := NewTripleDESCBC(make([]byte, keySize), iv)
+			require.Equal(t, ErrInvalidTripleDESKeySize, err)
+		}
+	})
+
+	t.Run("invalid iv size", func(t *testing.T) {
+		_, err := NewDESCBC([]byte("8bytekey"), []byte("16_bytes_aes_iv_"))
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+	})
+}
+
+func TestBlowfishTwofishCBC(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("blowfish", func(t *testing.T) {
+		iv := []byte("8bytesiv")
+		for _, key := range [][]byte{[]byte("k"), []byte("blowfish key"), make([]byte, 56)} {
+			bc, err := NewBlowfishCBC(key, iv)
+			require.NoError(t, err)
+			assert.Equal(t, blowfish.BlockSize, bc.BlockSize())
+			cipherText, err := bc.Encrypt(plainText)
+			require.NoError(t, err)
+			got, err := bc.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		_, err := NewBlowfishCBC(nil, iv)
+		require.Equal(t, ErrInvalidBlowfishKeySize, err)
+		_, err = NewBlowfishCBC(make([]byte, 57), iv)
+		require.Equal(t, ErrInvalidBlowfishKeySize, err)
+		_, err = NewBlowfishCBC([]byte("k"), []byte("16_bytes_aes_iv_"))
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+	})
+
+	t.Run("twofish", func(t *testing.T) {
+		iv := []byte("16_bytes_twofish")
+		for _, keySize := range []int{16, 24, 32} {
+			bc, err := NewTwofishCBC(make([]byte, keySize), iv)
+			require.NoError(t, err)
+			assert.Equal(t, twofish.BlockSize, bc.BlockSize())
+			cipherText, err := bc.Encrypt(plainText)
+			require.NoError(t, err)
+			got, err := bc.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		for _, keySize := range []int{0, 8, 20, 33} {
+			_, err := NewTwofishCBC(make([]byte, keySize), iv)
+			require.Equal(t, ErrInvalidTwofishKeySize, err)
+		}
+		_, err := NewTwofishCBC(make([]byte, 16), iv[:8])
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+	})
+}
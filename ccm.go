@@ -0,0 +1,163 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// error defined
+var (
+	ErrInvalidTagSize    = errors.New("invalid tag size")
+	ErrBlockSizeNot128   = errors.New("cipher block size must be 128 bits")
+	ErrPlainTextTooLarge = errors.New("plain text too large")
+)
+
+const ccmBlockSize = 16
+
+// NewCCM new ccm(RFC 3610, NIST SP 800-38C) aead with newCipher, key and custom option,
+// nonceSize must be between 7 and 13 bytes, the message length field size is 15 - nonceSize,
+// so the short nonce allows longer message, such as 13 bytes nonce allows up to 64KB.
+// tagSize must be one of 4, 6, 8, 10, 12, 14 and 16.
+// newCipher must be with 128-bit block size, such as aes, twofish.
+// option support:
+//      WithRand
+func NewCCM(key []byte, nonceSize, tagSize int, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (AEADCrypt, error) {
+	if nonceSize < 7 || nonceSize > 13 {
+		return nil, ErrInvalidNonceSize
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize&1 != 0 {
+		return nil, ErrInvalidTagSize
+	}
+	block, err := newBlock(newCipher, key)
+	if err != nil {
+		return nil, err
+	}
+	if block.BlockSize() != ccmBlockSize {
+		return nil, ErrBlockSizeNot128
+	}
+	return &aeadCrypt{&ccm{block, nonceSize, tagSize}, newConfig(opts...).rand}, nil
+}
+
+// ccm implement cipher.AEAD, ctr for encryption and cbc-mac for authentication.
+type ccm struct {
+	block     cipher.Block
+	nonceSize int
+	tagSize   int
+}
+
+func (sf *ccm) NonceSize() int { return sf.nonceSize }
+
+func (sf *ccm) Overhead() int { return sf.tagSize }
+
+// maxPlainTextSize the message length must fit in the length field with 15 - nonceSize bytes.
+func (sf *ccm) maxPlainTextSize() uint64 {
+	l := uint(15 - sf.nonceSize)
+	if l >= 8 {
+		return 1<<63 - 1
+	}
+	return 1<<(8*l) - 1
+}
+
+func (sf *ccm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != sf.nonceSize {
+		panic("aesext: incorrect nonce length given to ccm")
+	}
+	if uint64(len(plaintext)) > sf.maxPlainTextSize() {
+		panic("aesext: message too large for ccm")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+sf.tagSize)
+	tag := sf.tag(nonce, plaintext, additionalData)
+	sf.ctr(nonce).XORKeyStream(out, plaintext)
+	copy(out[len(plaintext):], tag)
+	return ret
+}
+
+func (sf *ccm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != sf.nonceSize {
+		panic("aesext: incorrect nonce length given to ccm")
+	}
+	if len(ciphertext) < sf.tagSize || uint64(len(ciphertext)-sf.tagSize) > sf.maxPlainTextSize() {
+		return nil, ErrAuthFailed
+	}
+	tag := ciphertext[len(ciphertext)-sf.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-sf.tagSize]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	sf.ctr(nonce).XORKeyStream(out, ciphertext)
+	if !constantTimeEqual(tag, sf.tag(nonce, out, additionalData)) {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+// counter returns the counter block A_i.
+func (sf *ccm) counter(nonce []byte, i byte) []byte {
+	a := make([]byte, ccmBlockSize)
+	a[0] = byte(14 - sf.nonceSize) // L - 1
+	copy(a[1:], nonce)
+	a[ccmBlockSize-1] = i
+	return a
+}
+
+// ctr returns the key stream start from A_1, A_0 is reserved for the tag.
+func (sf *ccm) ctr(nonce []byte) cipher.Stream {
+	return cipher.NewCTR(sf.block, sf.counter(nonce, 1))
+}
+
+// tag cbc-mac over B_0, the encoded additional data and the plain text,
+// then encrypted with S_0.
+func (sf *ccm) tag(nonce, plaintext, additionalData []byte) []byte {
+	l := 15 - sf.nonceSize
+	b := make([]byte, ccmBlockSize)
+	b[0] = byte((sf.tagSize-2)/2<<3 | (l - 1))
+	if len(additionalData) > 0 {
+		b[0] |= 0x40
+	}
+	copy(b[1:], nonce)
+	for i, n := ccmBlockSize-1, uint64(len(plaintext)); i > sf.nonceSize; i, n = i-1, n>>8 {
+		b[i] = byte(n)
+	}
+
+	x := make([]byte, ccmBlockSize)
+	sf.block.Encrypt(x, b)
+	if len(additionalData) > 0 {
+		var header []byte
+		switch n := uint64(len(additionalData)); {
+		case n < 0xff00:
+			header = make([]byte, 2)
+			binary.BigEndian.PutUint16(header, uint16(n))
+		case n <= 0xffffffff:
+			header = make([]byte, 6)
+			header[0], header[1] = 0xff, 0xfe
+			binary.BigEndian.PutUint32(header[2:], uint32(n))
+		default:
+			header = make([]byte, 10)
+			header[0], header[1] = 0xff, 0xff
+			binary.BigEndian.PutUint64(header[2:], n)
+		}
+		sf.cbcMAC(x, append(header, additionalData...))
+	}
+	sf.cbcMAC(x, plaintext)
+
+	s0 := sf.counter(nonce, 0)
+	sf.block.Encrypt(s0, s0)
+	xorBytes(x, x, s0)
+	return x[:sf.tagSize]
+}
+
+// cbcMAC update the cbc-mac state x with data, which is zero padded to the block size.
+func (sf *ccm) cbcMAC(x, data []byte) {
+	for len(data) > 0 {
+		n := xorBytes(x, x, data)
+		sf.block.Encrypt(x, x)
+		data = data[n:]
+	}
+}
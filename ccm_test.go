@@ -0,0 +1,94 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCCM(t *testing.T) {
+	key, _ := hex.DecodeString("c0c1c2c3c4c5c6c7c8c9cacbcccdcecf")
+	plainText, _ := hex.DecodeString("08090a0b0c0d0e0f101112131415161718191a1b1c1d1e")
+
+	t.Run("rfc 3610 vector", func(t *testing.T) {
+		// RFC 3610 Packet Vector #1
+		nonce, _ := hex.DecodeString("00000003020100a0a1a2a3a4a5")
+		additionalData, _ := hex.DecodeString("0001020304050607")
+
+		ad, err := NewCCM(key, 13, 8, aes.NewCipher)
+		require.NoError(t, err)
+		assert.Equal(t, 13, ad.NonceSize())
+		assert.Equal(t, 8, ad.Overhead())
+
+		cipherText := ad.Seal(nil, nonce, plainText, additionalData)
+		assert.Equal(t, "588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0", hex.EncodeToString(cipherText))
+
+		got, err := ad.Open(nil, nonce, cipherText, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, err = ad.Open(nil, nonce, cipherText, nil)
+		require.Equal(t, ErrAuthFailed, err)
+		cipherText[0] ^= 0x01
+		_, err = ad.Open(nil, nonce, cipherText, additionalData)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("without additional data", func(t *testing.T) {
+		nonce, _ := hex.DecodeString("10111213141516")
+		ad, err := NewCCM(key, 7, 16, aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText := ad.Seal(nil, nonce, plainText, nil)
+		assert.Equal(t, "022523619b4ce88bf8a6b05f7324ebca103c16c33ad1d55ae4375fb77f05eb5b52a1c8014a4979", hex.EncodeToString(cipherText))
+
+		got, err := ad.Open(nil, nonce, cipherText, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("random nonce", func(t *testing.T) {
+		ad, err := NewCCM(key, 12, 16, aes.NewCipher)
+		require.NoError(t, err)
+		blob, err := ad.SealRandom(plainText, []byte("header"))
+		require.NoError(t, err)
+		got, err := ad.OpenRandom(blob, []byte("header"))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("payload too large", func(t *testing.T) {
+		// 13 bytes nonce, 2 bytes length field
+		ad, err := NewCCM(key, 13, 8, aes.NewCipher)
+		require.NoError(t, err)
+		require.Panics(t, func() { ad.Seal(nil, make([]byte, 13), make([]byte, 1<<16), nil) })
+		_, err = ad.SealRandom(make([]byte, 1<<16), nil)
+		require.Equal(t, ErrPlainTextTooLarge, err)
+		require.NotPanics(t, func() { ad.Seal(nil, make([]byte, 13), make([]byte, 1<<16-1), nil) })
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, nonceSize := range []int{6, 14} {
+			_, err := NewCCM(key, nonceSize, 16, aes.NewCipher)
+			require.Equal(t, ErrInvalidNonceSize, err)
+		}
+		for _, tagSize := range []int{2, 5, 18} {
+			_, err := NewCCM(key, 12, tagSize, aes.NewCipher)
+			require.Equal(t, ErrInvalidTagSize, err)
+		}
+		_, err := NewCCM(key, 12, 16, mockErrorNewCipher)
+		require.Error(t, err)
+		_, err = NewCCM(key[:8], 12, 16, des.NewCipher)
+		require.Equal(t, ErrBlockSizeNot128, err)
+
+		ad, err := NewCCM(key, 12, 16, aes.NewCipher)
+		require.NoError(t, err)
+		require.Panics(t, func() { ad.Seal(nil, make([]byte, 13), plainText, nil) })
+		_, err = ad.Open(nil, make([]byte, 12), make([]byte, 15), nil)
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+}
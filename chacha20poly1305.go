@@ -0,0 +1,41 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// WithXChaCha20 option use XChaCha20-Poly1305 with 24 bytes nonce, default ChaCha20-Poly1305 with 12 bytes nonce.
+// the longer nonce is safe to be generated randomly, see SealRandom.
+func WithXChaCha20() Option {
+	return func(c *config) {
+		c.xchacha20 = true
+	}
+}
+
+// NewChaCha20Poly1305 new ChaCha20-Poly1305(RFC 8439) aead with 32 bytes key and custom option,
+// it is faster than aes-gcm on the platforms without aes hardware,
+// and implement the same AEADCrypt as gcm, so callers can switch algorithms transparently.
+// option support:
+//      WithXChaCha20
+//      WithRand
+func NewChaCha20Poly1305(key []byte, opts ...Option) (AEADCrypt, error) {
+	c := newConfig(opts...)
+	var aead cipher.AEAD
+	var err error
+	if c.xchacha20 {
+		aead, err = chacha20poly1305.NewX(key)
+	} else {
+		aead, err = chacha20poly1305.New(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("aesext: create cipher: %w", err)
+	}
+	return &aeadCrypt{aead, c.rand}, nil
+}
@@ -0,0 +1,59 @@
+package aesext
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaCha20Poly1305(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(0x80 + i)
+	}
+	additionalData, _ := hex.DecodeString("50515253c0c1c2c3c4c5c6c7")
+	plainText := []byte("Ladies and Gentlemen of the class of '99")
+
+	t.Run("rfc 8439 vector", func(t *testing.T) {
+		nonce, _ := hex.DecodeString("070000004041424344454647")
+		ad, err := NewChaCha20Poly1305(key)
+		require.NoError(t, err)
+		assert.Equal(t, 12, ad.NonceSize())
+		assert.Equal(t, 16, ad.Overhead())
+
+		cipherText := ad.Seal(nil, nonce, plainText, additionalData)
+		assert.Equal(t, "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d63dbea45e8ca96712f180d4e9016c65a7dde15e3106075ebd",
+			hex.EncodeToString(cipherText))
+
+		got, err := ad.Open(nil, nonce, cipherText, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, err = ad.Open(nil, nonce, cipherText, nil)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("xchacha20", func(t *testing.T) {
+		ad, err := NewChaCha20Poly1305(key, WithXChaCha20())
+		require.NoError(t, err)
+		assert.Equal(t, 24, ad.NonceSize())
+
+		blob, err := ad.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, 24+len(plainText)+16, len(blob))
+		got, err := ad.OpenRandom(blob, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		require.Panics(t, func() { ad.Seal(nil, make([]byte, 12), plainText, nil) })
+	})
+
+	t.Run("invalid key length", func(t *testing.T) {
+		_, err := NewChaCha20Poly1305(key[:16])
+		require.Error(t, err)
+		_, err = NewChaCha20Poly1305(key[:16], WithXChaCha20())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+)
+
+// cmac implement CMAC(OMAC1), see NIST SP 800-38B and RFC 4493,
+// support 64-bit and 128-bit block cipher.
+type cmac struct {
+	block  cipher.Block
+	k1, k2 []byte
+}
+
+func newCMAC(block cipher.Block) *cmac {
+	l := make([]byte, block.BlockSize())
+	block.Encrypt(l, l)
+	k1 := dbl(l)
+	return &cmac{block, k1, dbl(k1)}
+}
+
+// sum returns the mac of msg
+func (sf *cmac) sum(msg []byte) []byte {
+	blockSize := sf.block.BlockSize()
+	x := make([]byte, blockSize)
+	for len(msg) > blockSize {
+		xorBytes(x, x, msg[:blockSize])
+		sf.block.Encrypt(x, x)
+		msg = msg[blockSize:]
+	}
+	// the final block
+	if len(msg) == blockSize {
+		xorBytes(x, x, msg)
+		xorBytes(x, x, sf.k1)
+	} else {
+		xorBytes(x, x, msg)
+		x[len(msg)] ^= 0x80
+		xorBytes(x, x, sf.k2)
+	}
+	sf.block.Encrypt(x, x)
+	return x
+}
+
+// dbl multiplication by x in GF(2^n), return a new slice.
+func dbl(b []byte) []byte {
+	out := make([]byte, len(b))
+	var carry byte
+	for i := len(b) - 1; i >= 0; i-- {
+		out[i] = b[i]<<1 | carry
+		carry = b[i] >> 7
+	}
+	if carry != 0 {
+		if len(b) == 8 {
+			out[len(out)-1] ^= 0x1b
+		} else {
+			out[len(out)-1] ^= 0x87
+		}
+	}
+	return out
+}
+
+// xorBytes sets dst[i] = x[i] ^ y[i] for i < n = min(len(x), len(y)), return n.
+func xorBytes(dst, x, y []byte) int {
+	n := len(x)
+	if len(y) < n {
+		n = len(y)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = x[i] ^ y[i]
+	}
+	return n
+}
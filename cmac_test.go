@@ -0,0 +1,40 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCMAC(t *testing.T) {
+	// RFC 4493 section 4 test vectors
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172a" +
+		"ae2d8a571e03ac9c9eb76fac45af8e51" +
+		"30c81c46a35ce411e5fbc1191a0a52ef" +
+		"f69f2445df4f9b17ad2b417be66c3710")
+	tests := []struct {
+		name   string
+		length int
+		want   string
+	}{
+		{"empty", 0, "bb1d6929e95937287fa37d129b756746"},
+		{"16 bytes", 16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{"40 bytes", 40, "dfa66747de9ae63030ca32611497c827"},
+		{"64 bytes", 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	mac := newCMAC(block)
+	assert.Equal(t, "fbeed618357133667c85e08f7236a8de", hex.EncodeToString(mac.k1))
+	assert.Equal(t, "f7ddac306ae266ccf90bc11ee46d513b", hex.EncodeToString(mac.k2))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hex.EncodeToString(mac.sum(msg[:tt.length])))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/aes"
+)
+
+// Base64Crypt BlockCrypt with base64.StdEncoding string helpers,
+// for interoperate with other languages which exchange the cipher text as base64 string.
+type Base64Crypt interface {
+	BlockCrypt
+	// EncryptToBase64 encrypt plain text, return the cipher text encoded with base64.StdEncoding
+	EncryptToBase64(plainText []byte) (string, error)
+	// DecryptFromBase64 decode s with base64.StdEncoding, then decrypt it.
+	DecryptFromBase64(s string) ([]byte, error)
+}
+
+type base64Crypt struct {
+	BlockCrypt
+}
+
+// EncryptToBase64 implement Base64Crypt
+func (sf base64Crypt) EncryptToBase64(plainText []byte) (string, error) {
+	return EncryptBase64(sf.BlockCrypt, plainText)
+}
+
+// DecryptFromBase64 implement Base64Crypt
+func (sf base64Crypt) DecryptFromBase64(s string) ([]byte, error) {
+	return DecryptBase64(sf.BlockCrypt, s)
+}
+
+// NewCryptoJSCompat new crypt compatible with CryptoJS.AES using a passphrase, with custom option.
+// it is aes-256-cbc with PKCS7 padding, key and iv derived by EVPBytesToKey(md5) with a random salt,
+// the cipher text is `Salted__` + 8 bytes salt + cipher text, see NewOpenSSLCrypt.
+//      EncryptToBase64 output can be decrypted by: CryptoJS.AES.decrypt(s, passphrase)
+//      DecryptFromBase64 input can be encrypted by: CryptoJS.AES.encrypt(msg, passphrase).toString()
+// option support: WithRand, the salt random source.
+func NewCryptoJSCompat(passphrase []byte, opts ...Option) (Base64Crypt, error) {
+	bc, err := NewOpenSSLCrypt(passphrase, 32, aes.NewCipher, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return base64Crypt{bc}, nil
+}
+
+// NewJavaCompatCBC new aes cbc crypt with a fixed iv and PKCS7 padding, key must be 16, 24 or 32 bytes,
+// it matches Java Cipher.getInstance("AES/CBC/PKCS5Padding") with new IvParameterSpec(iv),
+// and the same for PHP openssl_encrypt(data, "aes-128-cbc", key, 0, iv),
+// Java PKCS5Padding is actually PKCS#7 padding with 16 bytes block.
+//      EncryptToBase64 output equal to: Base64.getEncoder().encodeToString(cipher.doFinal(data))
+//      DecryptFromBase64 input from: cipher.doFinal(Base64.getDecoder().decode(s))
+// NOTE: a fixed iv leaks the equality of message prefixes, only use it for interoperate with legacy system.
+func NewJavaCompatCBC(key, iv []byte) (Base64Crypt, error) {
+	bc, err := NewAESCBC(key, iv, WithPadding(PKCS7{}))
+	if err != nil {
+		return nil, err
+	}
+	return base64Crypt{bc}, nil
+}
@@ -0,0 +1,96 @@
+package aesext
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoJSCompat(t *testing.T) {
+	// CryptoJS.AES.encrypt(msg, passphrase).toString() output is the same as
+	// openssl enc -aes-256-cbc -md md5 -pass pass:passphrase -base64 -A
+	vectors := []struct {
+		passphrase string
+		salt       []byte
+		plainText  string
+		cipherText string
+	}{
+		{
+			"secret",
+			[]byte{0xa0, 0x8a, 0xc9, 0xe7, 0x65, 0x6b, 0x41, 0xce},
+			"helloworld,this is golang language. welcome",
+			"U2FsdGVkX1+gisnnZWtBzvetJ/EI1YwPUX88900qvT4ZIz3mSwoV2CRsB6Kctvnrvuxvm75fF+WnVHHro9fuvw==",
+		},
+		{
+			"passphrase",
+			[]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			"Message from CryptoJS",
+			"U2FsdGVkX18BAgMEBQYHCGqivZ4mF7rprGBD4R/+YUzNjJGftPDL250PVYStkIkE",
+		},
+	}
+
+	t.Run("decrypt", func(t *testing.T) {
+		for _, v := range vectors {
+			bc, err := NewCryptoJSCompat([]byte(v.passphrase))
+			require.NoError(t, err)
+			got, err := bc.DecryptFromBase64(v.cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, v.plainText, string(got))
+		}
+	})
+
+	t.Run("encrypt", func(t *testing.T) {
+		for _, v := range vectors {
+			bc, err := NewCryptoJSCompat([]byte(v.passphrase), WithRand(bytes.NewReader(v.salt)))
+			require.NoError(t, err)
+			got, err := bc.EncryptToBase64([]byte(v.plainText))
+			require.NoError(t, err)
+			assert.Equal(t, v.cipherText, got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		bc, err := NewCryptoJSCompat([]byte("secret"))
+		require.NoError(t, err)
+		_, err = bc.DecryptFromBase64("!invalid base64")
+		require.Error(t, err)
+		_, err = bc.DecryptFromBase64(vectors[1].cipherText)
+		require.Error(t, err)
+	})
+}
+
+func TestJavaCompatCBC(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	iv := []byte{0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x00}
+	// Cipher.getInstance("AES/CBC/PKCS5Padding") output, the same as
+	// openssl enc -aes-128-cbc -K 000102030405060708090a0b0c0d0e0f -iv 0f0e0d0c0b0a09080706050403020100 -base64 -A
+	plainText := "hello java backend"
+	cipherText := "biq9UroBMdWrtHS3hfyWgYZ3UvhXSIAEQir7vonuxuM="
+
+	t.Run("interop", func(t *testing.T) {
+		bc, err := NewJavaCompatCBC(key, iv)
+		require.NoError(t, err)
+
+		got, err := bc.EncryptToBase64([]byte(plainText))
+		require.NoError(t, err)
+		assert.Equal(t, cipherText, got)
+
+		plain, err := bc.DecryptFromBase64(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, string(plain))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewJavaCompatCBC(key[:8], iv)
+		require.Equal(t, ErrInvalidAESKeySize, err)
+		_, err = NewJavaCompatCBC(key, iv[:8])
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+
+		bc, err := NewJavaCompatCBC(key, iv)
+		require.NoError(t, err)
+		_, err = bc.DecryptFromBase64("!invalid base64")
+		require.Error(t, err)
+	})
+}
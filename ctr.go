@@ -0,0 +1,61 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+)
+
+// CTRCrypt ctr mode crypt, it supports random access by seeking the counter.
+type CTRCrypt interface {
+	BlockCrypt
+	// StreamAt returns a cipher.Stream positioned at the byte offset of the key stream,
+	// it enables decrypting any part of a large cipher text without processing the prefix.
+	// it panics if offset is negative.
+	StreamAt(offset int64) cipher.Stream
+}
+
+// NewCTRCrypt new ctr mode with newCipher, key, iv and custom option.
+// ctr is a stream mode, the cipher text length equal plain text length, no padding.
+// the iv is the initial counter block, which is incremented as a big-endian integer.
+func NewCTRCrypt(key, iv []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (CTRCrypt, error) {
+	bc, err := NewBlockCrypt(key, iv, newCipher,
+		append([]Option{WithStreamCodec(cipher.NewCTR, cipher.NewCTR)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &ctrCrypt{bc.(*blockBlock)}, nil
+}
+
+type ctrCrypt struct {
+	*blockBlock
+}
+
+// Clone clone
+func (sf *ctrCrypt) Clone() BlockCrypt {
+	return &ctrCrypt{sf.blockBlock.Clone().(*blockBlock)}
+}
+
+// StreamAt returns the stream at offset
+func (sf *ctrCrypt) StreamAt(offset int64) cipher.Stream {
+	if offset < 0 {
+		panic("aesext: negative offset given to StreamAt")
+	}
+	blockSize := int64(sf.block.BlockSize())
+	counter := append([]byte{}, sf.iv...)
+	// counter += offset / blockSize
+	carry := uint64(offset / blockSize)
+	for i := len(counter) - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(counter[i]) + carry&0xff
+		counter[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	stream := cipher.NewCTR(sf.block, counter)
+	if skip := offset % blockSize; skip > 0 {
+		discard := make([]byte, skip)
+		stream.XORKeyStream(discard, discard)
+	}
+	return stream
+}
@@ -0,0 +1,70 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCTRCrypt(t *testing.T) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	plainText := make([]byte, 4096)
+	for i := range plainText {
+		plainText[i] = byte(i * 7)
+	}
+
+	bc, err := NewCTRCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(t, err)
+	cipherText, err := bc.Encrypt(plainText)
+	require.NoError(t, err)
+	require.Len(t, cipherText, len(plainText))
+
+	t.Run("stream at", func(t *testing.T) {
+		for _, offset := range []int64{0, 15, 16, 1000, 4080} {
+			got := make([]byte, 16)
+			bc.StreamAt(offset).XORKeyStream(got, cipherText[offset:offset+16])
+			assert.Equal(t, plainText[offset:offset+16], got, offset)
+		}
+	})
+
+	t.Run("counter carry", func(t *testing.T) {
+		// the low bytes of the counter overflow
+		iv := bytes.Repeat([]byte{0xff}, aes.BlockSize)
+		iv[0] = 0x00
+		bc, err := NewCTRCrypt(key[:16], iv, aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+
+		got := make([]byte, 16)
+		bc.StreamAt(1000).XORKeyStream(got, cipherText[1000:1016])
+		assert.Equal(t, plainText[1000:1016], got)
+	})
+
+	t.Run("same as ctr stream codec", func(t *testing.T) {
+		cloned := bc.Clone()
+		_, ok := cloned.(CTRCrypt)
+		require.True(t, ok)
+		got, err := cloned.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		got, err = ioutil.ReadAll(NewDecryptReader(bytes.NewReader(cipherText), bc))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("negative offset", func(t *testing.T) {
+		require.Panics(t, func() { bc.StreamAt(-1) })
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewCTRCrypt(key[:16], iv[:8], aes.NewCipher)
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+	})
+}
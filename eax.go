@@ -0,0 +1,100 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+)
+
+const eaxBlockSize = 16
+
+// WithEAXTagSize option eax tag size, default 16 bytes, it must be between 1 and 16 bytes.
+// NOTE: a truncated tag reduces the authentication strength.
+func WithEAXTagSize(size int) Option {
+	return func(c *config) {
+		c.eaxTagSize = size
+	}
+}
+
+// NewEAX new eax aead with newCipher, key, nonceSize and custom option,
+// eax is a two-pass aead which combine ctr and omac(cmac), the nonce can be of any positive length.
+// newCipher must be with 128-bit block size, such as aes, twofish.
+// option support:
+//      WithEAXTagSize
+//      WithRand
+func NewEAX(key []byte, nonceSize int, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (AEADCrypt, error) {
+	c := newConfig(opts...)
+	if nonceSize <= 0 {
+		return nil, ErrInvalidNonceSize
+	}
+	if c.eaxTagSize < 1 || c.eaxTagSize > eaxBlockSize {
+		return nil, ErrInvalidTagSize
+	}
+	block, err := newBlock(newCipher, key)
+	if err != nil {
+		return nil, err
+	}
+	if block.BlockSize() != eaxBlockSize {
+		return nil, ErrBlockSizeNot128
+	}
+	return &aeadCrypt{&eax{block, newCMAC(block), nonceSize, c.eaxTagSize}, c.rand}, nil
+}
+
+// eax implement cipher.AEAD
+type eax struct {
+	block     cipher.Block
+	mac       *cmac
+	nonceSize int
+	tagSize   int
+}
+
+func (sf *eax) NonceSize() int { return sf.nonceSize }
+
+func (sf *eax) Overhead() int { return sf.tagSize }
+
+func (sf *eax) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != sf.nonceSize {
+		panic("aesext: incorrect nonce length given to eax")
+	}
+	n := sf.omac(0, nonce)
+	ret, out := sliceForAppend(dst, len(plaintext)+sf.tagSize)
+	cipher.NewCTR(sf.block, n).XORKeyStream(out, plaintext)
+	copy(out[len(plaintext):], sf.tag(n, out[:len(plaintext)], additionalData))
+	return ret
+}
+
+func (sf *eax) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != sf.nonceSize {
+		panic("aesext: incorrect nonce length given to eax")
+	}
+	if len(ciphertext) < sf.tagSize {
+		return nil, ErrAuthFailed
+	}
+	tag := ciphertext[len(ciphertext)-sf.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-sf.tagSize]
+
+	n := sf.omac(0, nonce)
+	if !constantTimeEqual(tag, sf.tag(n, ciphertext, additionalData)) {
+		return nil, ErrAuthFailed
+	}
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	cipher.NewCTR(sf.block, n).XORKeyStream(out, ciphertext)
+	return ret, nil
+}
+
+// tag T = N ^ H ^ C, where H = OMAC^1(header) and C = OMAC^2(cipher text).
+func (sf *eax) tag(n, ciphertext, additionalData []byte) []byte {
+	t := sf.omac(1, additionalData)
+	xorBytes(t, t, n)
+	xorBytes(t, t, sf.omac(2, ciphertext))
+	return t[:sf.tagSize]
+}
+
+// omac OMAC^t(msg) = CMAC([t]_n || msg), [t]_n is t encoded as a full block.
+func (sf *eax) omac(t byte, msg []byte) []byte {
+	b := make([]byte, eaxBlockSize, eaxBlockSize+len(msg))
+	b[eaxBlockSize-1] = t
+	return sf.mac.sum(append(b, msg...))
+}
@@ -0,0 +1,92 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEAX(t *testing.T) {
+	t.Run("eax paper vectors", func(t *testing.T) {
+		tests := []struct {
+			key, nonce, header, msg, cipher string
+		}{
+			{
+				"233952dee4d5ed5f9b9c6d6ff80ff478",
+				"62ec67f9c3a4a407fcb2a8c49031a8b3",
+				"6bfb914fd07eae6b",
+				"",
+				"e037830e8389f27b025a2d6527e79d01",
+			},
+			{
+				"91945d3f4dcbee0bf45ef52255f095a4",
+				"becaf043b0a23d843194ba972c66debd",
+				"fa3bfd4806eb53fa",
+				"f7fb",
+				"19dd5c4c9331049d0bdab0277408f67967e5",
+			},
+		}
+		for _, tt := range tests {
+			key, _ := hex.DecodeString(tt.key)
+			nonce, _ := hex.DecodeString(tt.nonce)
+			header, _ := hex.DecodeString(tt.header)
+			msg, _ := hex.DecodeString(tt.msg)
+
+			ad, err := NewEAX(key, len(nonce), aes.NewCipher)
+			require.NoError(t, err)
+			assert.Equal(t, 16, ad.Overhead())
+
+			cipherText := ad.Seal(nil, nonce, msg, header)
+			assert.Equal(t, tt.cipher, hex.EncodeToString(cipherText))
+
+			got, err := ad.Open(nil, nonce, cipherText, header)
+			require.NoError(t, err)
+			assert.Equal(t, hex.EncodeToString(msg), hex.EncodeToString(got))
+
+			_, err = ad.Open(nil, nonce, cipherText, nil)
+			require.Equal(t, ErrAuthFailed, err)
+		}
+	})
+
+	t.Run("tag size", func(t *testing.T) {
+		key := make([]byte, 16)
+		plainText := []byte("helloworld,this is golang language. welcome")
+		ad, err := NewEAX(key, 12, aes.NewCipher, WithEAXTagSize(8))
+		require.NoError(t, err)
+		assert.Equal(t, 8, ad.Overhead())
+
+		full, err := NewEAX(key, 12, aes.NewCipher)
+		require.NoError(t, err)
+
+		nonce := make([]byte, 12)
+		cipherText := ad.Seal(nil, nonce, plainText, nil)
+		want := full.Seal(nil, nonce, plainText, nil)
+		// truncated tag
+		assert.Equal(t, want[:len(plainText)+8], cipherText)
+
+		got, err := ad.Open(nil, nonce, cipherText, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+		cipherText[len(cipherText)-1] ^= 0x01
+		_, err = ad.Open(nil, nonce, cipherText, nil)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		key := make([]byte, 16)
+		_, err := NewEAX(key, 0, aes.NewCipher)
+		require.Equal(t, ErrInvalidNonceSize, err)
+		_, err = NewEAX(key, 12, aes.NewCipher, WithEAXTagSize(0))
+		require.Equal(t, ErrInvalidTagSize, err)
+		_, err = NewEAX(key, 12, aes.NewCipher, WithEAXTagSize(17))
+		require.Equal(t, ErrInvalidTagSize, err)
+		_, err = NewEAX(key, 12, mockErrorNewCipher)
+		require.Error(t, err)
+		_, err = NewEAX(key[:8], 12, des.NewCipher)
+		require.Equal(t, ErrBlockSizeNot128, err)
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ErrInvalidAESKeySize aes key length must be 16, 24 or 32 bytes
+var ErrInvalidAESKeySize = errors.New("aes key length must be 16, 24 or 32 bytes")
+
+// ParseKeyHex decode the hex encoded key, the surrounding white space is ignored,
+// return ErrInvalidAESKeySize if the key length is not 16, 24 or 32 bytes.
+func ParseKeyHex(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	return checkAESKey(key)
+}
+
+// ParseKeyBase64 decode the base64.StdEncoding encoded key, the surrounding white space is ignored,
+// return ErrInvalidAESKeySize if the key length is not 16, 24 or 32 bytes.
+func ParseKeyBase64(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	return checkAESKey(key)
+}
+
+func checkAESKey(key []byte) ([]byte, error) {
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, ErrInvalidAESKeySize
+	}
+}
+
+// EncryptBase64 encrypt plain text, return the cipher text encoded with base64.StdEncoding
+func EncryptBase64(bc BlockCrypt, plainText []byte) (string, error) {
+	return encryptBase64(bc, base64.StdEncoding, plainText)
+}
+
+// DecryptBase64 decode s with base64.StdEncoding, then decrypt it.
+func DecryptBase64(bc BlockCrypt, s string) ([]byte, error) {
+	return decryptBase64(bc, base64.StdEncoding, s)
+}
+
+// EncryptURLBase64 encrypt plain text, return the cipher text encoded with base64.URLEncoding
+func EncryptURLBase64(bc BlockCrypt, plainText []byte) (string, error) {
+	return encryptBase64(bc, base64.URLEncoding, plainText)
+}
+
+// DecryptURLBase64 decode s with base64.URLEncoding, then decrypt it.
+func DecryptURLBase64(bc BlockCrypt, s string) ([]byte, error) {
+	return decryptBase64(bc, base64.URLEncoding, s)
+}
+
+func encryptBase64(bc BlockCrypt, enc *base64.Encoding, plainText []byte) (string, error) {
+	cipherText, err := bc.Encrypt(plainText)
+	if err != nil {
+		return "", err
+	}
+	return enc.EncodeToString(cipherText), nil
+}
+
+func decryptBase64(bc BlockCrypt, enc *base64.Encoding, s string) ([]byte, error) {
+	cipherText, err := enc.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return bc.Decrypt(cipherText)
+}
+
+// EncryptHex encrypt plain text, return the cipher text encoded with hex
+func EncryptHex(bc BlockCrypt, plainText []byte) (string, error) {
+	cipherText, err := bc.Encrypt(plainText)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(cipherText), nil
+}
+
+// DecryptHex decode s with hex, then decrypt it.
+// it returns hex.InvalidByteError if s contains invalid hex character,
+// hex.ErrLength if s has odd length.
+func DecryptHex(bc BlockCrypt, s string) ([]byte, error) {
+	cipherText, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return bc.Decrypt(cipherText)
+}
+
+// EncryptString encrypt plain text string
+func EncryptString(bc BlockCrypt, s string) ([]byte, error) {
+	return bc.Encrypt([]byte(s))
+}
+
+// DecryptString decrypt cipher text, return the plain text as string
+func DecryptString(bc BlockCrypt, cipherText []byte) (string, error) {
+	plainText, err := bc.Decrypt(cipherText)
+	if err != nil {
+		return "", err
+	}
+	return string(plainText), nil
+}
+
+// EncryptJSON marshal v to json with json.Marshal, then encrypt it.
+// the error is wrapped with the stage, "json marshal" or "encrypt", it can be unwrapped by errors.Is or errors.As.
+func EncryptJSON(bc BlockCrypt, v interface{}) ([]byte, error) {
+	plainText, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("aesext: json marshal: %w", err)
+	}
+	cipherText, err := bc.Encrypt(plainText)
+	if err != nil {
+		return nil, fmt.Errorf("aesext: encrypt: %w", err)
+	}
+	return cipherText, nil
+}
+
+// DecryptJSON decrypt cipher text, then unmarshal the json plain text to v with json.Unmarshal.
+// the error is wrapped with the stage, "decrypt" or "json unmarshal", see EncryptJSON.
+func DecryptJSON(bc BlockCrypt, cipherText []byte, v interface{}) error {
+	plainText, err := bc.Decrypt(cipherText)
+	if err != nil {
+		return fmt.Errorf("aesext: decrypt: %w", err)
+	}
+	if err = json.Unmarshal(plainText, v); err != nil {
+		return fmt.Errorf("aesext: json unmarshal: %w", err)
+	}
+	return nil
+}
+
+// EncryptReader read all of r, then encrypt it, limit is the max plain text size in bytes,
+// return ErrPlainTextTooLarge if r has more data than limit, limit <= 0 means unlimited.
+// for large data, use NewEncryptWriter which not hold the whole data in memory.
+func EncryptReader(bc BlockCrypt, r io.Reader, limit int64) ([]byte, error) {
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	plainText, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(plainText)) > limit {
+		return nil, ErrPlainTextTooLarge
+	}
+	return bc.Encrypt(plainText)
+}
+
+// DecryptWriter decrypt cipher text, then write the plain text to w,
+// nothing is written if decrypt failed.
+func DecryptWriter(bc BlockCrypt, w io.Writer, cipherText []byte) error {
+	plainText, err := bc.Decrypt(cipherText)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(plainText)
+	return err
+}
@@ -0,0 +1,196 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase64(t *testing.T) {
+	bc, err := New([]byte("test"), []byte("a"))
+	require.NoError(t, err)
+
+	plainText := []byte("test")
+
+	t.Run("std", func(t *testing.T) {
+		s, err := EncryptBase64(bc, plainText)
+		require.NoError(t, err)
+		assert.Equal(t, "zqIqKKqf74cJ4l3a+MbA5Q==", s)
+
+		got, err := DecryptBase64(bc, s)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, err = DecryptBase64(bc, "zqIqKKqf74cJ4l3a+MbA5Q")
+		require.IsType(t, base64.CorruptInputError(0), err)
+	})
+	t.Run("url", func(t *testing.T) {
+		s, err := EncryptURLBase64(bc, plainText)
+		require.NoError(t, err)
+		assert.Equal(t, "zqIqKKqf74cJ4l3a-MbA5Q==", s)
+
+		got, err := DecryptURLBase64(bc, s)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, err = DecryptURLBase64(bc, "zqIqKKqf74cJ4l3a+MbA5Q==")
+		require.IsType(t, base64.CorruptInputError(0), err)
+	})
+	t.Run("decrypt failed", func(t *testing.T) {
+		_, err := DecryptBase64(bc, "zg==")
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+}
+
+func TestHex(t *testing.T) {
+	bc, err := New([]byte("test"), []byte("a"))
+	require.NoError(t, err)
+
+	plainText := []byte("test")
+
+	s, err := EncryptHex(bc, plainText)
+	require.NoError(t, err)
+	assert.Equal(t, "cea22a28aa9fef8709e25ddaf8c6c0e5", s)
+
+	got, err := DecryptHex(bc, s)
+	require.NoError(t, err)
+	assert.Equal(t, plainText, got)
+
+	_, err = DecryptHex(bc, "cea22a28aa9fef8709e25ddaf8c6c0ez")
+	require.Equal(t, hex.InvalidByteError('z'), err)
+	_, err = DecryptHex(bc, "cea22a28aa9fef8709e25ddaf8c6c0e")
+	require.Equal(t, hex.ErrLength, err)
+	_, err = DecryptHex(bc, "ce")
+	require.Equal(t, ErrInputNotMultipleBlocks, err)
+}
+
+func TestString(t *testing.T) {
+	bc, err := New([]byte("test"), []byte("a"))
+	require.NoError(t, err)
+
+	cipherText, err := EncryptString(bc, "test")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xce, 0xa2, 0x2a, 0x28, 0xaa, 0x9f, 0xef, 0x87,
+		0x09, 0xe2, 0x5d, 0xda, 0xf8, 0xc6, 0xc0, 0xe5}, cipherText)
+
+	got, err := DecryptString(bc, cipherText)
+	require.NoError(t, err)
+	assert.Equal(t, "test", got)
+
+	_, err = DecryptString(bc, cipherText[:1])
+	require.Equal(t, ErrInputNotMultipleBlocks, err)
+}
+
+func TestJSON(t *testing.T) {
+	bc, err := New([]byte("test"), []byte("a"))
+	require.NoError(t, err)
+
+	type user struct {
+		Name  string `json:"name"`
+		Phone string `json:"phone"`
+	}
+	want := user{"name", "13800000000"}
+	cipherText, err := EncryptJSON(bc, want)
+	require.NoError(t, err)
+	plainText, err := bc.Decrypt(cipherText)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"name","phone":"13800000000"}`, string(plainText))
+
+	var got user
+	require.NoError(t, DecryptJSON(bc, cipherText, &got))
+	assert.Equal(t, want, got)
+
+	_, err = EncryptJSON(bc, make(chan int))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "json marshal")
+	var typeErr *json.UnsupportedTypeError
+	require.True(t, errors.As(err, &typeErr))
+
+	err = DecryptJSON(bc, cipherText[:1], &got)
+	assert.Contains(t, err.Error(), "decrypt")
+	require.True(t, errors.Is(err, ErrInputNotMultipleBlocks))
+
+	notJSON, err := bc.Encrypt([]byte("not json"))
+	require.NoError(t, err)
+	err = DecryptJSON(bc, notJSON, &got)
+	assert.Contains(t, err.Error(), "json unmarshal")
+	var syntaxErr *json.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+
+	raw, err := NewBlockCrypt([]byte("0123456789abcdef"), []byte("fedcba9876543210"), aes.NewCipher, WithNoPadding())
+	require.NoError(t, err)
+	_, err = EncryptJSON(raw, want)
+	assert.Contains(t, err.Error(), "encrypt")
+	require.True(t, errors.Is(err, ErrInputNotMultipleBlocks))
+}
+
+func TestReaderWriter(t *testing.T) {
+	bc, err := New([]byte("test"), []byte("a"))
+	require.NoError(t, err)
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	cipherText, err := EncryptReader(bc, bytes.NewReader(plainText), 0)
+	require.NoError(t, err)
+	want, err := bc.Encrypt(plainText)
+	require.NoError(t, err)
+	assert.Equal(t, want, cipherText)
+
+	_, err = EncryptReader(bc, bytes.NewReader(plainText), int64(len(plainText)))
+	require.NoError(t, err)
+	_, err = EncryptReader(bc, bytes.NewReader(plainText), int64(len(plainText)-1))
+	require.Equal(t, ErrPlainTextTooLarge, err)
+	_, err = EncryptReader(bc, iotest.TimeoutReader(bytes.NewReader(plainText)), 0)
+	require.Equal(t, iotest.ErrTimeout, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, DecryptWriter(bc, out, cipherText))
+	assert.Equal(t, plainText, out.Bytes())
+
+	out.Reset()
+	require.Equal(t, ErrInputNotMultipleBlocks, DecryptWriter(bc, out, cipherText[:1]))
+	assert.Zero(t, out.Len())
+}
+
+func TestParseKey(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+
+	t.Run("hex", func(t *testing.T) {
+		for _, keySize := range aesKeySizes {
+			got, err := ParseKeyHex(hex.EncodeToString(key[:keySize]) + "\n")
+			require.NoError(t, err)
+			assert.Equal(t, key[:keySize], got)
+		}
+		_, err := ParseKeyHex(hex.EncodeToString(key[:20]))
+		require.Equal(t, ErrInvalidAESKeySize, err)
+		_, err = ParseKeyHex("not hex")
+		require.Error(t, err)
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		for _, keySize := range aesKeySizes {
+			got, err := ParseKeyBase64(" " + base64.StdEncoding.EncodeToString(key[:keySize]))
+			require.NoError(t, err)
+			assert.Equal(t, key[:keySize], got)
+		}
+		_, err := ParseKeyBase64(base64.StdEncoding.EncodeToString(key[:8]))
+		require.Equal(t, ErrInvalidAESKeySize, err)
+		_, err = ParseKeyBase64("not base64!")
+		require.Error(t, err)
+	})
+
+	t.Run("compose", func(t *testing.T) {
+		k, err := ParseKeyHex(hex.EncodeToString(key[:16]))
+		require.NoError(t, err)
+		_, err = NewBlockCrypt(k, key[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrEnvNotSet environment variable not set or empty
+var ErrEnvNotSet = errors.New("environment variable not set")
+
+// NewBlockCryptFromEnv new with newCipher, the key and iv read from the named environment variables
+// and custom option, see NewBlockCrypt. the values are encoded with base64.StdEncoding,
+// the surrounding white space is trimmed. if ivEnv is empty, it creates the random iv crypt,
+// see NewBlockCryptRandomIV.
+// the error names the variable, and wraps ErrEnvNotSet if it is missing or empty,
+// the base64 error if it is malformed, or the length error, such as aes.KeySizeError and ErrInvalidIvSize.
+//	bc, err := NewBlockCryptFromEnv("APP_AES_KEY", "APP_AES_IV", aes.NewCipher)
+func NewBlockCryptFromEnv(keyEnv, ivEnv string, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (BlockCrypt, error) {
+	key, err := lookupEnvBase64(keyEnv)
+	if err != nil {
+		return nil, err
+	}
+	if ivEnv == "" {
+		bc, err := NewBlockCryptRandomIV(key, newCipher, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("aesext: env %s: %w", keyEnv, err)
+		}
+		return bc, nil
+	}
+	iv, err := lookupEnvBase64(ivEnv)
+	if err != nil {
+		return nil, err
+	}
+	bc, err := NewBlockCrypt(key, iv, newCipher, opts...)
+	if err != nil {
+		name := keyEnv
+		if errors.Is(err, ErrInvalidIvSize) || errors.Is(err, ErrWeakIV) {
+			name = ivEnv
+		}
+		return nil, fmt.Errorf("aesext: env %s: %w", name, err)
+	}
+	return bc, nil
+}
+
+func lookupEnvBase64(name string) ([]byte, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return nil, fmt.Errorf("aesext: env %s: %w", name, ErrEnvNotSet)
+	}
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("aesext: env %s: %w", name, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,98 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setTestEnv(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		require.NoError(t, os.Setenv(k, v))
+	}
+	t.Cleanup(func() {
+		for k := range env {
+			os.Unsetenv(k) // nolint: errcheck
+		}
+	})
+}
+
+func TestNewBlockCryptFromEnv(t *testing.T) {
+	key, iv := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("fixed iv", func(t *testing.T) {
+		setTestEnv(t, map[string]string{
+			"AESEXT_TEST_KEY": base64.StdEncoding.EncodeToString(key),
+			"AESEXT_TEST_IV":  " " + base64.StdEncoding.EncodeToString(iv) + "\n",
+		})
+		bc, err := NewBlockCryptFromEnv("AESEXT_TEST_KEY", "AESEXT_TEST_IV", aes.NewCipher)
+		require.NoError(t, err)
+		want, err := NewBlockCrypt(key, iv, aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+		wantCipherText, err := want.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, wantCipherText, cipherText)
+	})
+
+	t.Run("random iv", func(t *testing.T) {
+		setTestEnv(t, map[string]string{"AESEXT_TEST_KEY": base64.StdEncoding.EncodeToString(key)})
+		bc, err := NewBlockCryptFromEnv("AESEXT_TEST_KEY", "", aes.NewCipher)
+		require.NoError(t, err)
+		want, err := NewBlockCryptRandomIV(key, aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+		got, err := want.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		setTestEnv(t, map[string]string{
+			"AESEXT_TEST_KEY":       base64.StdEncoding.EncodeToString(key),
+			"AESEXT_TEST_IV":        base64.StdEncoding.EncodeToString(iv),
+			"AESEXT_TEST_EMPTY":     "  ",
+			"AESEXT_TEST_MALFORMED": "not base64!",
+			"AESEXT_TEST_SHORT":     base64.StdEncoding.EncodeToString(key[:10]),
+		})
+		tests := []struct {
+			name     string
+			keyEnv   string
+			ivEnv    string
+			wantName string
+			wantErr  error
+		}{
+			{"key not set", "AESEXT_TEST_MISSING", "AESEXT_TEST_IV", "AESEXT_TEST_MISSING", ErrEnvNotSet},
+			{"iv empty", "AESEXT_TEST_KEY", "AESEXT_TEST_EMPTY", "AESEXT_TEST_EMPTY", ErrEnvNotSet},
+			{"key malformed", "AESEXT_TEST_MALFORMED", "AESEXT_TEST_IV", "AESEXT_TEST_MALFORMED", nil},
+			{"iv malformed", "AESEXT_TEST_KEY", "AESEXT_TEST_MALFORMED", "AESEXT_TEST_MALFORMED", nil},
+			{"key length", "AESEXT_TEST_SHORT", "AESEXT_TEST_IV", "AESEXT_TEST_SHORT", nil},
+			{"random iv key length", "AESEXT_TEST_SHORT", "", "AESEXT_TEST_SHORT", nil},
+			{"iv length", "AESEXT_TEST_KEY", "AESEXT_TEST_SHORT", "AESEXT_TEST_SHORT", ErrInvalidIvSize},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := NewBlockCryptFromEnv(tt.keyEnv, tt.ivEnv, aes.NewCipher)
+				require.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), tt.wantName), err.Error())
+				if tt.wantErr != nil {
+					assert.True(t, errors.Is(err, tt.wantErr), err.Error())
+				}
+			})
+		}
+		var keySizeError aes.KeySizeError
+		_, err := NewBlockCryptFromEnv("AESEXT_TEST_SHORT", "AESEXT_TEST_IV", aes.NewCipher)
+		require.True(t, errors.As(err, &keySizeError))
+	})
+}
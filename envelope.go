@@ -0,0 +1,128 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"errors"
+)
+
+// ErrUnknownAlgorithm unknown algorithm id
+var ErrUnknownAlgorithm = errors.New("unknown algorithm")
+
+// EnvelopeVersion1 the current envelope format version
+const EnvelopeVersion1 = 0x01
+
+// Algorithm the algorithm id of the envelope, the value never changes once released,
+// new algorithm is always assigned a new id.
+type Algorithm byte
+
+// algorithm id defined
+const (
+	AlgorithmAESCBC            Algorithm = 0x01 // 16 bytes iv
+	AlgorithmAESGCM            Algorithm = 0x02 // 12 bytes nonce
+	AlgorithmChaCha20Poly1305  Algorithm = 0x03 // 12 bytes nonce
+	AlgorithmXChaCha20Poly1305 Algorithm = 0x04 // 24 bytes nonce
+	AlgorithmAESCBCHMACSHA256  Algorithm = 0x05 // 16 bytes iv, see NewEncryptThenMAC
+	AlgorithmAESSIV            Algorithm = 0x06 // no nonce
+	AlgorithmAESCTR            Algorithm = 0x07 // 16 bytes iv
+	AlgorithmAESGCMSIV         Algorithm = 0x08 // 12 bytes nonce
+)
+
+// envelopeNonceSizes the iv/nonce size of the algorithm
+var envelopeNonceSizes = map[Algorithm]int{
+	AlgorithmAESCBC:            16,
+	AlgorithmAESGCM:            12,
+	AlgorithmChaCha20Poly1305:  12,
+	AlgorithmXChaCha20Poly1305: 24,
+	AlgorithmAESCBCHMACSHA256:  16,
+	AlgorithmAESSIV:            0,
+	AlgorithmAESCTR:            16,
+	AlgorithmAESGCMSIV:         12,
+}
+
+// Envelope versioned, self-describing cipher text envelope, the format version 1:
+//
+//	version(1) | algorithm id(1) | iv/nonce(the algorithm's nonce size) | cipher text
+//
+// so the blob stored now can be decrypted after change the algorithm later without ambiguity.
+type Envelope struct {
+	Version    byte
+	Algorithm  Algorithm
+	Nonce      []byte
+	CipherText []byte
+}
+
+// PackEnvelope pack the algorithm id, iv/nonce and cipher text with the current version,
+// return ErrUnknownAlgorithm if the algorithm is unknown,
+// ErrInvalidNonceSize if the nonce length not equal the algorithm's nonce size.
+func PackEnvelope(alg Algorithm, nonce, cipherText []byte) ([]byte, error) {
+	nonceSize, ok := envelopeNonceSizes[alg]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+	if len(nonce) != nonceSize {
+		return nil, ErrInvalidNonceSize
+	}
+	blob := make([]byte, 0, 2+len(nonce)+len(cipherText))
+	blob = append(blob, EnvelopeVersion1, byte(alg))
+	blob = append(blob, nonce...)
+	return append(blob, cipherText...), nil
+}
+
+// UnpackEnvelope unpack the blob packed by PackEnvelope, the Nonce and CipherText
+// share the blob's storage. return ErrUnsupportedVersion if the version is unknown,
+// ErrUnknownAlgorithm if the algorithm is unknown, ErrCipherTextTooShort if the blob is truncated.
+func UnpackEnvelope(blob []byte) (*Envelope, error) {
+	if len(blob) < 2 {
+		return nil, ErrCipherTextTooShort
+	}
+	if blob[0] != EnvelopeVersion1 {
+		return nil, ErrUnsupportedVersion
+	}
+	alg := Algorithm(blob[1])
+	nonceSize, ok := envelopeNonceSizes[alg]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+	if len(blob) < 2+nonceSize {
+		return nil, ErrCipherTextTooShort
+	}
+	return &Envelope{
+		Version:    blob[0],
+		Algorithm:  alg,
+		Nonce:      blob[2 : 2+nonceSize],
+		CipherText: blob[2+nonceSize:],
+	}, nil
+}
+
+// PackNonce pack the nonce with a 1 byte length header, the format:
+//
+//	nonce length(1) | nonce | cipher text
+//
+// so a single decrypt function works across the algorithms with different nonce sizes,
+// such as gcm(12 bytes) and xchacha20-poly1305(24 bytes).
+// return ErrInvalidNonceSize if the nonce is longer than 255 bytes.
+func PackNonce(nonce, cipherText []byte) ([]byte, error) {
+	if len(nonce) > 255 {
+		return nil, ErrInvalidNonceSize
+	}
+	blob := make([]byte, 0, 1+len(nonce)+len(cipherText))
+	blob = append(blob, byte(len(nonce)))
+	blob = append(blob, nonce...)
+	return append(blob, cipherText...), nil
+}
+
+// UnpackNonce unpack the blob packed by PackNonce, the nonce and cipher text
+// share the blob's storage, return ErrCipherTextTooShort if the blob is truncated.
+func UnpackNonce(blob []byte) (nonce, cipherText []byte, err error) {
+	if len(blob) < 1 {
+		return nil, nil, ErrCipherTextTooShort
+	}
+	nonceSize := int(blob[0])
+	if len(blob) < 1+nonceSize {
+		return nil, nil, ErrCipherTextTooShort
+	}
+	return blob[1 : 1+nonceSize], blob[1+nonceSize:], nil
+}
@@ -0,0 +1,142 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelope(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("pack and unpack", func(t *testing.T) {
+		ad, err := NewAEAD(key[:], aes.NewCipher)
+		require.NoError(t, err)
+		nonce := []byte("unique_nonce")
+		cipherText := ad.Seal(nil, nonce, plainText, nil)
+
+		blob, err := PackEnvelope(AlgorithmAESGCM, nonce, cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{EnvelopeVersion1, byte(AlgorithmAESGCM)}, blob[:2])
+
+		env, err := UnpackEnvelope(blob)
+		require.NoError(t, err)
+		assert.Equal(t, byte(EnvelopeVersion1), env.Version)
+		assert.Equal(t, AlgorithmAESGCM, env.Algorithm)
+		assert.Equal(t, nonce, env.Nonce)
+		got, err := ad.Open(nil, env.Nonce, env.CipherText, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("no nonce", func(t *testing.T) {
+		blob, err := PackEnvelope(AlgorithmAESSIV, nil, []byte{0x01, 0x02})
+		require.NoError(t, err)
+		env, err := UnpackEnvelope(blob)
+		require.NoError(t, err)
+		assert.Empty(t, env.Nonce)
+		assert.Equal(t, []byte{0x01, 0x02}, env.CipherText)
+	})
+
+	t.Run("backward compatibility", func(t *testing.T) {
+		// the blobs packed by the released version must keep unpacking
+		// when the new algorithm ids are added.
+		envelopeNonceSizes[Algorithm(0xfe)] = 32
+		defer delete(envelopeNonceSizes, Algorithm(0xfe))
+
+		golden := []struct {
+			blob  string
+			alg   Algorithm
+			nonce string
+		}{
+			{"0101000102030405060708090a0b0c0d0e0fc0ffee", AlgorithmAESCBC, "000102030405060708090a0b0c0d0e0f"},
+			{"0102000102030405060708090a0bc0ffee", AlgorithmAESGCM, "000102030405060708090a0b"},
+			{"0103000102030405060708090a0bc0ffee", AlgorithmChaCha20Poly1305, "000102030405060708090a0b"},
+			{"0104000102030405060708090a0b0c0d0e0f1011121314151617c0ffee", AlgorithmXChaCha20Poly1305, "000102030405060708090a0b0c0d0e0f1011121314151617"},
+		}
+		for _, tt := range golden {
+			blob, _ := hex.DecodeString(tt.blob)
+			env, err := UnpackEnvelope(blob)
+			require.NoError(t, err, tt.blob)
+			assert.Equal(t, tt.alg, env.Algorithm)
+			assert.Equal(t, tt.nonce, hex.EncodeToString(env.Nonce))
+			assert.Equal(t, "c0ffee", hex.EncodeToString(env.CipherText))
+
+			packed, err := PackEnvelope(env.Algorithm, env.Nonce, env.CipherText)
+			require.NoError(t, err)
+			assert.Equal(t, blob, packed)
+		}
+
+		// the new algorithm id
+		blob, err := PackEnvelope(Algorithm(0xfe), make([]byte, 32), []byte{0x01})
+		require.NoError(t, err)
+		env, err := UnpackEnvelope(blob)
+		require.NoError(t, err)
+		assert.Equal(t, Algorithm(0xfe), env.Algorithm)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := PackEnvelope(Algorithm(0xff), nil, nil)
+		require.Equal(t, ErrUnknownAlgorithm, err)
+		_, err = PackEnvelope(AlgorithmAESGCM, make([]byte, 16), nil)
+		require.Equal(t, ErrInvalidNonceSize, err)
+
+		_, err = UnpackEnvelope([]byte{EnvelopeVersion1})
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = UnpackEnvelope([]byte{0x02, byte(AlgorithmAESGCM)})
+		require.Equal(t, ErrUnsupportedVersion, err)
+		_, err = UnpackEnvelope([]byte{EnvelopeVersion1, 0xff})
+		require.Equal(t, ErrUnknownAlgorithm, err)
+		_, err = UnpackEnvelope([]byte{EnvelopeVersion1, byte(AlgorithmAESGCM), 0x01})
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+}
+
+func TestPackNonce(t *testing.T) {
+	key := make([]byte, 32)
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	gcm, err := NewAEAD(key, aes.NewCipher)
+	require.NoError(t, err)
+	xchacha, err := NewChaCha20Poly1305(key, WithXChaCha20())
+	require.NoError(t, err)
+
+	for _, ad := range []AEADCrypt{gcm, xchacha} {
+		nonce := make([]byte, ad.NonceSize())
+		blob, err := PackNonce(nonce, ad.Seal(nil, nonce, plainText, nil))
+		require.NoError(t, err)
+		assert.Equal(t, byte(ad.NonceSize()), blob[0])
+
+		gotNonce, cipherText, err := UnpackNonce(blob)
+		require.NoError(t, err)
+		assert.Equal(t, nonce, gotNonce)
+		got, err := ad.Open(nil, gotNonce, cipherText, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		blob, err := PackNonce(nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x00}, blob)
+		nonce, cipherText, err := UnpackNonce(blob)
+		require.NoError(t, err)
+		assert.Empty(t, nonce)
+		assert.Empty(t, cipherText)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := PackNonce(make([]byte, 256), nil)
+		require.Equal(t, ErrInvalidNonceSize, err)
+
+		_, _, err = UnpackNonce(nil)
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, _, err = UnpackNonce([]byte{12, 0x01, 0x02})
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+}
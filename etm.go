@@ -0,0 +1,103 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"io"
+)
+
+// ErrMACMismatch message authentication code mismatch
+var ErrMACMismatch = errors.New("message authentication code mismatch")
+
+// NewEncryptThenMAC new encrypt-then-mac crypt with newCipher, encKey, macKey and custom option,
+// Encrypt cbc encrypt with a fresh random iv, then append a HMAC-SHA256 over iv + cipher text,
+// Decrypt verify the mac before decrypting, return ErrMACMismatch if the mac doesn't verify.
+// so it gives authenticated encryption without requiring gcm.
+// encKey and macKey should be independent keys, macKey should be at least 32 bytes.
+func NewEncryptThenMAC(encKey, macKey []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (BlockCrypt, error) {
+	bc, err := NewBlockCryptRandomIV(encKey, newCipher, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptThenMAC{bc, append([]byte{}, macKey...)}, nil
+}
+
+type encryptThenMAC struct {
+	bc     BlockCrypt
+	macKey []byte
+}
+
+func (sf *encryptThenMAC) BlockSize() int {
+	return sf.bc.BlockSize()
+}
+
+// Encrypt encrypt
+func (sf *encryptThenMAC) Encrypt(plainText []byte) ([]byte, error) {
+	return sf.EncryptTo(nil, plainText)
+}
+
+// EncryptTo encrypt
+func (sf *encryptThenMAC) EncryptTo(dst, plainText []byte) ([]byte, error) {
+	start := len(dst)
+	dst, err := sf.bc.EncryptTo(dst, plainText)
+	if err != nil {
+		return nil, err
+	}
+	return sf.mac(dst, dst[start:]), nil
+}
+
+// Decrypt decrypt
+func (sf *encryptThenMAC) Decrypt(cipherText []byte) ([]byte, error) {
+	return sf.DecryptTo(nil, cipherText)
+}
+
+// DecryptTo decrypt, the mac is verified in constant time before decrypting,
+// so the decryption timing doesn't leak how much of the tag is valid.
+func (sf *encryptThenMAC) DecryptTo(dst, cipherText []byte) ([]byte, error) {
+	if len(cipherText) < sha256.Size {
+		return nil, ErrCipherTextTooShort
+	}
+	cipherText, tag := cipherText[:len(cipherText)-sha256.Size], cipherText[len(cipherText)-sha256.Size:]
+	if !constantTimeEqual(tag, sf.mac(nil, cipherText)) {
+		return nil, ErrMACMismatch
+	}
+	return sf.bc.DecryptTo(dst, cipherText)
+}
+
+// Clone clone
+func (sf *encryptThenMAC) Clone() BlockCrypt {
+	return &encryptThenMAC{sf.bc.Clone(), append([]byte{}, sf.macKey...)}
+}
+
+// Close zero the mac key, it implement io.Closer.
+// using the BlockCrypt after Close is undefined.
+func (sf *encryptThenMAC) Close() error {
+	for i := range sf.macKey {
+		sf.macKey[i] = 0
+	}
+	if c, ok := sf.bc.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// mac appends HMAC-SHA256 of data to dst and returns the updated slice.
+func (sf *encryptThenMAC) mac(dst, data []byte) []byte {
+	h := hmac.New(sha256.New, sf.macKey)
+	h.Write(data) // nolint: errcheck
+	return h.Sum(dst)
+}
+
+// constantTimeEqual reports whether x and y are equal, the time taken depends on
+// the length of the slices only, never on their contents, so it doesn't leak
+// where the first mismatch byte is. use it to compare mac or tag, never bytes.Equal.
+func constantTimeEqual(x, y []byte) bool {
+	return subtle.ConstantTimeCompare(x, y) == 1
+}
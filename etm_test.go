@@ -0,0 +1,96 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptThenMAC(t *testing.T) {
+	encKey, macKey := sha256.Sum256([]byte("enc_key")), sha256.Sum256([]byte("mac_key"))
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, keySize := range aesKeySizes {
+			bc, err := NewEncryptThenMAC(encKey[:keySize], macKey[:], aes.NewCipher)
+			require.NoError(t, err)
+			assert.Equal(t, aes.BlockSize, bc.BlockSize())
+
+			cipherText, err := bc.Encrypt(plainText)
+			require.NoError(t, err)
+			// iv + cipher text + tag
+			assert.Equal(t, aes.BlockSize+48+sha256.Size, len(cipherText))
+
+			got, err := bc.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+
+			got, err = bc.Clone().Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+
+			dst, err := bc.EncryptTo([]byte("prefix"), plainText)
+			require.NoError(t, err)
+			got, err = bc.DecryptTo([]byte("prefix"), dst[6:])
+			require.NoError(t, err)
+			assert.Equal(t, append([]byte("prefix"), plainText...), got)
+		}
+	})
+
+	t.Run("mac mismatch", func(t *testing.T) {
+		bc, err := NewEncryptThenMAC(encKey[:16], macKey[:], aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+
+		for _, i := range []int{0, aes.BlockSize, len(cipherText) - 1} {
+			tampered := append([]byte{}, cipherText...)
+			tampered[i] ^= 0x01
+			_, err = bc.Decrypt(tampered)
+			require.Equal(t, ErrMACMismatch, err)
+		}
+
+		other, err := NewEncryptThenMAC(encKey[:16], macKey[:16], aes.NewCipher)
+		require.NoError(t, err)
+		_, err = other.Decrypt(cipherText)
+		require.Equal(t, ErrMACMismatch, err)
+
+		_, err = bc.Decrypt(cipherText[:sha256.Size-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		bc, err := NewEncryptThenMAC(encKey[:16], macKey[:], aes.NewCipher)
+		require.NoError(t, err)
+		require.NoError(t, bc.(io.Closer).Close())
+		assert.Equal(t, make([]byte, sha256.Size), bc.(*encryptThenMAC).macKey)
+	})
+
+	t.Run("invalid cipher", func(t *testing.T) {
+		_, err := NewEncryptThenMAC(encKey[:16], macKey[:], mockErrorNewCipher)
+		require.Error(t, err)
+	})
+}
+
+func TestConstantTimeEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []byte
+		want bool
+	}{
+		{"empty", []byte{}, nil, true},
+		{"equal", []byte{0x01, 0x02, 0x03}, []byte{0x01, 0x02, 0x03}, true},
+		{"first byte differ", []byte{0x01, 0x02, 0x03}, []byte{0x00, 0x02, 0x03}, false},
+		{"last byte differ", []byte{0x01, 0x02, 0x03}, []byte{0x01, 0x02, 0x04}, false},
+		{"length differ", []byte{0x01, 0x02, 0x03}, []byte{0x01, 0x02}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, constantTimeEqual(tt.x, tt.y))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+//go:build go1.18
+// +build go1.18
+
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+func FuzzDecrypt(f *testing.F) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+
+	var crypts []BlockCrypt
+	for _, opts := range [][]Option{
+		nil,
+		{WithPadding(Zero{})},
+		{WithPadding(X923{})},
+		{WithPadding(ISO10126{})},
+		{WithPadding(ISO7816{})},
+		{WithPadding(PKCS7ConstantTime{})},
+		{WithNoPadding()},
+		{WithIVPrefix()},
+		{WithStreamCodec(cipher.NewCTR, cipher.NewCTR)},
+	} {
+		bc, err := NewBlockCrypt(key, iv, aes.NewCipher, opts...)
+		if err != nil {
+			f.Fatal(err)
+		}
+		crypts = append(crypts, bc)
+	}
+	randomIV, err := NewBlockCryptRandomIV(key, aes.NewCipher)
+	if err != nil {
+		f.Fatal(err)
+	}
+	crypts = append(crypts, randomIV)
+
+	cipherText, err := crypts[0].Encrypt([]byte("helloworld,this is golang language. welcome"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(cipherText)
+	f.Add([]byte{})
+	f.Add(make([]byte, aes.BlockSize))
+	f.Add(make([]byte, 2*aes.BlockSize))
+	// the padding length equal and exceed the block size
+	noPadding, err := NewBlockCrypt(key, iv, aes.NewCipher, WithNoPadding())
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, pad := range []byte{aes.BlockSize, aes.BlockSize + 1, 0xff} {
+		padded := make([]byte, aes.BlockSize)
+		for i := range padded {
+			padded[i] = pad
+		}
+		seed, err := noPadding.Encrypt(padded)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, bc := range crypts {
+			plainText, err := bc.Decrypt(data)
+			if err == nil && len(plainText) > len(data) {
+				t.Fatalf("plain text length %d exceeds cipher text length %d", len(plainText), len(data))
+			}
+		}
+	})
+}
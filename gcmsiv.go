@@ -0,0 +1,223 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidGCMSIVKeySize gcm-siv key length must be 16 or 32 bytes
+var ErrInvalidGCMSIVKeySize = errors.New("gcm-siv key length must be 16 or 32 bytes")
+
+// gcm-siv parameters, see RFC 8452
+const (
+	gcmSIVNonceSize    = 12
+	gcmSIVTagSize      = 16
+	gcmSIVMaxPlainText = 1 << 36
+)
+
+// NewGCMSIV new aes gcm-siv aead with key and custom option, see RFC 8452.
+// the key must be 16 bytes for AES-128-GCM-SIV or 32 bytes for AES-256-GCM-SIV,
+// the nonce is 12 bytes and the tag is 16 bytes.
+// it is nonce-misuse-resistant, if a nonce is accidentally reused, it only leaks whether the two
+// plain text (with the same additional data) are identical, but not the key or the plain text.
+// option support: WithRand
+func NewGCMSIV(key []byte, opts ...Option) (AEADCrypt, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, ErrInvalidGCMSIVKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &aeadCrypt{&gcmSIV{block, len(key)}, newConfig(opts...).rand}, nil
+}
+
+// gcmSIV implement cipher.AEAD
+type gcmSIV struct {
+	block  cipher.Block // key generating block
+	keyLen int
+}
+
+func (sf *gcmSIV) NonceSize() int { return gcmSIVNonceSize }
+
+func (sf *gcmSIV) Overhead() int { return gcmSIVTagSize }
+
+func (sf *gcmSIV) maxPlainTextSize() uint64 { return gcmSIVMaxPlainText }
+
+func (sf *gcmSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("aesext: incorrect nonce length given to gcm-siv")
+	}
+	if uint64(len(plaintext)) > gcmSIVMaxPlainText || uint64(len(additionalData)) > gcmSIVMaxPlainText {
+		panic("aesext: message too large for gcm-siv")
+	}
+	authKey, encBlock := sf.deriveKeys(nonce)
+	tag := sf.tag(authKey, encBlock, nonce, plaintext, additionalData)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+gcmSIVTagSize)
+	gcmSIVCounter(encBlock, tag, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (sf *gcmSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("aesext: incorrect nonce length given to gcm-siv")
+	}
+	if len(ciphertext) < gcmSIVTagSize {
+		return nil, ErrCipherTextTooShort
+	}
+	if uint64(len(ciphertext)) > gcmSIVMaxPlainText+gcmSIVTagSize || uint64(len(additionalData)) > gcmSIVMaxPlainText {
+		return nil, ErrAuthFailed
+	}
+	var tag [gcmSIVTagSize]byte
+	copy(tag[:], ciphertext[len(ciphertext)-gcmSIVTagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-gcmSIVTagSize]
+
+	authKey, encBlock := sf.deriveKeys(nonce)
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	gcmSIVCounter(encBlock, tag, out, ciphertext)
+	expected := sf.tag(authKey, encBlock, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expected[:], tag[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+// deriveKeys derive the per-nonce message authentication key and message encryption key.
+func (sf *gcmSIV) deriveKeys(nonce []byte) ([16]byte, cipher.Block) {
+	var in, out [16]byte
+	var authKey [16]byte
+	encKey := make([]byte, sf.keyLen)
+
+	copy(in[4:], nonce)
+	for i := 0; i < 2+sf.keyLen/8; i++ {
+		binary.LittleEndian.PutUint32(in[:4], uint32(i))
+		sf.block.Encrypt(out[:], in[:])
+		if i < 2 {
+			copy(authKey[i*8:], out[:8])
+		} else {
+			copy(encKey[(i-2)*8:], out[:8])
+		}
+	}
+	encBlock, _ := aes.NewCipher(encKey) // the key length is always 16 or 32 bytes
+	return authKey, encBlock
+}
+
+func (sf *gcmSIV) tag(authKey [16]byte, encBlock cipher.Block, nonce, plaintext, additionalData []byte) [16]byte {
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+	p.update(lengths[:])
+
+	s := p.sum()
+	for i := range nonce {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+	encBlock.Encrypt(s[:], s[:])
+	return s
+}
+
+// gcmSIVCounter aes ctr with the tag as initial counter block, which most significant bit of
+// the last byte is set, the first 32 bits is a little endian counter.
+func gcmSIVCounter(block cipher.Block, tag [16]byte, dst, src []byte) {
+	var counter, keyStream [16]byte
+	counter = tag
+	counter[15] |= 0x80
+	for len(src) > 0 {
+		block.Encrypt(keyStream[:], counter[:])
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+		n := xorBytes(dst, src, keyStream[:])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// polyval the POLYVAL universal hash of RFC 8452, it is computed with GHASH arithmetic:
+// POLYVAL(H, X_1, ..., X_n) = ByteReverse(GHASH(mulX_GHASH(ByteReverse(H)), ByteReverse(X_1), ..., ByteReverse(X_n)))
+type polyval struct {
+	h, y gcmFieldElement
+}
+
+// gcmFieldElement GHASH field element, the bits are in big endian order.
+type gcmFieldElement struct {
+	hi, lo uint64
+}
+
+func newPolyval(key [16]byte) *polyval {
+	h := reverseFieldElement(key[:])
+	return &polyval{h: h.mulX()}
+}
+
+// update absorb data, the trailing partial block is zero padded.
+func (sf *polyval) update(data []byte) {
+	var block [16]byte
+	for len(data) > 0 {
+		n := copy(block[:], data)
+		for i := n; i < 16; i++ {
+			block[i] = 0
+		}
+		x := reverseFieldElement(block[:])
+		sf.y.hi ^= x.hi
+		sf.y.lo ^= x.lo
+		sf.y = sf.y.mul(sf.h)
+		data = data[n:]
+	}
+}
+
+func (sf *polyval) sum() [16]byte {
+	var out [16]byte
+	binary.LittleEndian.PutUint64(out[:8], sf.y.lo)
+	binary.LittleEndian.PutUint64(out[8:], sf.y.hi)
+	return out
+}
+
+// reverseFieldElement ByteReverse then load as GHASH field element.
+func reverseFieldElement(b []byte) gcmFieldElement {
+	return gcmFieldElement{
+		hi: binary.LittleEndian.Uint64(b[8:16]),
+		lo: binary.LittleEndian.Uint64(b[:8]),
+	}
+}
+
+// mulX multiply by x in GHASH field.
+func (sf gcmFieldElement) mulX() gcmFieldElement {
+	mask := -(sf.lo & 1)
+	return gcmFieldElement{
+		hi: (sf.hi >> 1) ^ (0xe100000000000000 & mask),
+		lo: sf.lo>>1 | sf.hi<<63,
+	}
+}
+
+// mul multiply in GHASH field, with no branch depending on the value.
+func (sf gcmFieldElement) mul(y gcmFieldElement) gcmFieldElement {
+	var z gcmFieldElement
+	v := y
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = (sf.hi >> (63 - i)) & 1
+		} else {
+			bit = (sf.lo >> (127 - i)) & 1
+		}
+		mask := -bit
+		z.hi ^= v.hi & mask
+		z.lo ^= v.lo & mask
+		v = v.mulX()
+	}
+	return z
+}
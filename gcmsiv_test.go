@@ -0,0 +1,125 @@
+package aesext
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolyval(t *testing.T) {
+	// RFC 8452 appendix A
+	var key [16]byte
+	h, _ := hex.DecodeString("25629347589242761d31f826ba4b757b")
+	copy(key[:], h)
+	x, _ := hex.DecodeString("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362")
+
+	p := newPolyval(key)
+	p.update(x)
+	got := p.sum()
+	assert.Equal(t, "f7a3b47b846119fae5b7866cf5e5b77e", hex.EncodeToString(got[:]))
+}
+
+func TestGCMSIV(t *testing.T) {
+	t.Run("rfc 8452 vectors", func(t *testing.T) {
+		// RFC 8452 appendix C.1 and C.2
+		tests := []struct {
+			key, nonce, plainText, ad, want string
+		}{
+			{
+				"01000000000000000000000000000000", "030000000000000000000000", "", "",
+				"dc20e2d83f25705bb49e439eca56de25",
+			},
+			{
+				"01000000000000000000000000000000", "030000000000000000000000", "0100000000000000", "",
+				"b5d839330ac7b786578782fff6013b815b287c22493a364c",
+			},
+			{
+				"01000000000000000000000000000000", "030000000000000000000000", "010000000000000000000000", "",
+				"7323ea61d05932260047d942a4978db357391a0bc4fdec8b0d106639",
+			},
+			{
+				"01000000000000000000000000000000", "030000000000000000000000", "0200000000000000", "01",
+				"1e6daba35669f4273b0a1a2560969cdf790d99759abd1508",
+			},
+			{
+				"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000", "", "",
+				"07f5f4169bbf55a8400cd47ea6fd400f",
+			},
+			{
+				"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000", "0100000000000000", "",
+				"c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+			},
+		}
+		for _, tt := range tests {
+			key, _ := hex.DecodeString(tt.key)
+			nonce, _ := hex.DecodeString(tt.nonce)
+			plainText, _ := hex.DecodeString(tt.plainText)
+			ad, _ := hex.DecodeString(tt.ad)
+
+			bc, err := NewGCMSIV(key)
+			require.NoError(t, err)
+			assert.Equal(t, 12, bc.NonceSize())
+			assert.Equal(t, 16, bc.Overhead())
+
+			cipherText := bc.Seal(nil, nonce, plainText, ad)
+			assert.Equal(t, tt.want, hex.EncodeToString(cipherText))
+
+			got, err := bc.Open(nil, nonce, cipherText, ad)
+			require.NoError(t, err)
+			assert.Equal(t, hex.EncodeToString(plainText), hex.EncodeToString(got))
+		}
+	})
+
+	t.Run("nonce reuse", func(t *testing.T) {
+		bc, err := NewGCMSIV(make([]byte, 32))
+		require.NoError(t, err)
+		nonce := make([]byte, 12)
+		plainText := []byte("helloworld,this is golang language. welcome")
+
+		cipherText1 := bc.Seal(nil, nonce, plainText, []byte("header"))
+		cipherText2 := bc.Seal(nil, nonce, plainText, []byte("header"))
+		assert.Equal(t, cipherText1, cipherText2)
+		cipherText3 := bc.Seal(nil, nonce, plainText[:len(plainText)-1], []byte("header"))
+		assert.NotEqual(t, cipherText1[:len(plainText)-1], cipherText3[:len(plainText)-1])
+
+		got, err := bc.Open(nil, nonce, cipherText1, []byte("header"))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		_, err = bc.Open(nil, nonce, cipherText1, nil)
+		require.Equal(t, ErrAuthFailed, err)
+		cipherText1[0] ^= 0x01
+		_, err = bc.Open(nil, nonce, cipherText1, []byte("header"))
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("random nonce", func(t *testing.T) {
+		bc, err := NewGCMSIV(make([]byte, 16))
+		require.NoError(t, err)
+		plainText := make([]byte, 1000)
+		blob, err := bc.SealRandom(plainText, []byte("header"))
+		require.NoError(t, err)
+		got, err := bc.OpenRandom(blob, []byte("header"))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, keySize := range []int{0, 24, 64} {
+			_, err := NewGCMSIV(make([]byte, keySize))
+			require.Equal(t, ErrInvalidGCMSIVKeySize, err)
+		}
+		bc, err := NewGCMSIV(make([]byte, 16))
+		require.NoError(t, err)
+		require.Panics(t, func() { bc.Seal(nil, make([]byte, 8), nil, nil) })
+		// the AEADCrypt checks the nonce, the raw cipher.AEAD panics as the standard library
+		_, err = bc.Open(nil, make([]byte, 8), make([]byte, 16), nil)
+		require.Equal(t, ErrInvalidNonceSize, err)
+		raw := bc.(*aeadCrypt).aead
+		require.Panics(t, func() { _, _ = raw.Open(nil, make([]byte, 8), make([]byte, 16), nil) })
+		_, err = bc.Open(nil, make([]byte, 12), make([]byte, 15), nil)
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+}
@@ -0,0 +1,254 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// ErrInvalidSegmentSize segment size must be positive
+var ErrInvalidSegmentSize = errors.New("segment size must be positive")
+
+// gcm stream nonce: prefix(7) | segment counter(4, big endian) | final flag(1)
+const (
+	defaultSegmentSize     = 64 << 10
+	gcmStreamPrefixSize    = 7
+	gcmStreamCounterOffset = gcmStreamPrefixSize
+	gcmStreamFinalOffset   = gcmStreamPrefixSize + 4
+)
+
+// WithSegmentSize option gcm stream plain text segment size in bytes, default 64KB, it must be positive.
+// the reader must use the same segment size as the writer.
+func WithSegmentSize(size int) Option {
+	return func(c *config) {
+		c.segmentSize = size
+	}
+}
+
+// NewGCMStreamWriter returns a writer, data written to it is split into fixed-size segments,
+// and each segment is gcm sealed and written to w, it is the STREAM construction.
+// the stream begins with a 7 bytes random nonce prefix, each segment nonce is
+// prefix + 4 bytes segment counter + 1 byte final flag, so the reader detects
+// reordered, dropped or truncated segments.
+// Close must be called to seal the final segment, it does not close the underlying writer.
+// newCipher must be with 128-bit block size, such as aes, twofish.
+// option support:
+//      WithSegmentSize
+//      WithRand
+func NewGCMStreamWriter(w io.Writer, key []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (io.WriteCloser, error) {
+	c := newConfig(opts...)
+	aead, err := newGCMStream(key, newCipher, c)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmStreamWriter{
+		w:           w,
+		aead:        aead,
+		rand:        c.rand,
+		segmentSize: c.segmentSize,
+	}, nil
+}
+
+// NewGCMStreamReader returns a reader, it reads the stream written by NewGCMStreamWriter from r,
+// open and verify it segment by segment, return ErrAuthFailed if any segment is tampered,
+// reordered, dropped, or the stream is truncated.
+// NOTE: the plain text of the verified segments is returned before the whole stream verified.
+// option support:
+//      WithSegmentSize
+func NewGCMStreamReader(r io.Reader, key []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (io.Reader, error) {
+	c := newConfig(opts...)
+	aead, err := newGCMStream(key, newCipher, c)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmStreamReader{
+		r:    r,
+		aead: aead,
+		// one more byte to detect whether the segment is the final one.
+		in: make([]byte, 0, c.segmentSize+aead.Overhead()+1),
+	}, nil
+}
+
+func newGCMStream(key []byte, newCipher func(key []byte) (cipher.Block, error), c config) (cipher.AEAD, error) {
+	if c.segmentSize <= 0 {
+		return nil, ErrInvalidSegmentSize
+	}
+	block, err := newBlock(newCipher, key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// gcmStreamNonce the segment nonce
+type gcmStreamNonce struct {
+	nonce   [gcmStandardNonceSize]byte
+	counter uint64
+}
+
+// next return the next segment nonce, return ErrNonceExhausted if the segment counter exhausted.
+func (sf *gcmStreamNonce) next(final bool) ([]byte, error) {
+	if sf.counter > math.MaxUint32 {
+		return nil, ErrNonceExhausted
+	}
+	binary.BigEndian.PutUint32(sf.nonce[gcmStreamCounterOffset:], uint32(sf.counter))
+	sf.nonce[gcmStreamFinalOffset] = 0x00
+	if final {
+		sf.nonce[gcmStreamFinalOffset] = 0x01
+	}
+	sf.counter++
+	return sf.nonce[:], nil
+}
+
+type gcmStreamWriter struct {
+	w           io.Writer
+	aead        cipher.AEAD
+	rand        io.Reader
+	segmentSize int
+	nonce       *gcmStreamNonce
+	buf         []byte // pending plain text which not fill a full segment
+	out         []byte
+	err         error
+}
+
+func (sf *gcmStreamWriter) init() error {
+	if sf.nonce != nil {
+		return nil
+	}
+	nonce := &gcmStreamNonce{}
+	prefix := nonce.nonce[:gcmStreamPrefixSize]
+	if _, err := io.ReadFull(sf.rand, prefix); err != nil {
+		return err
+	}
+	if _, err := sf.w.Write(prefix); err != nil {
+		return err
+	}
+	sf.nonce = nonce
+	return nil
+}
+
+func (sf *gcmStreamWriter) seal(segment []byte, final bool) error {
+	nonce, err := sf.nonce.next(final)
+	if err != nil {
+		return err
+	}
+	sf.out = sf.aead.Seal(sf.out[:0], nonce, segment, nil)
+	_, err = sf.w.Write(sf.out)
+	return err
+}
+
+// Write implement io.Writer
+func (sf *gcmStreamWriter) Write(p []byte) (int, error) {
+	if sf.err != nil {
+		return 0, sf.err
+	}
+	if sf.err = sf.init(); sf.err != nil {
+		return 0, sf.err
+	}
+
+	sf.buf = append(sf.buf, p...)
+	// held back a full segment until we know whether more data follows.
+	n := 0
+	for len(sf.buf)-n > sf.segmentSize {
+		if sf.err = sf.seal(sf.buf[n:n+sf.segmentSize], false); sf.err != nil {
+			return 0, sf.err
+		}
+		n += sf.segmentSize
+	}
+	sf.buf = append(sf.buf[:0], sf.buf[n:]...)
+	return len(p), nil
+}
+
+// Close seal the final segment, it does not close the underlying writer.
+func (sf *gcmStreamWriter) Close() error {
+	if sf.err != nil {
+		if sf.err == ErrWriterClosed {
+			return nil
+		}
+		return sf.err
+	}
+	if sf.err = sf.init(); sf.err != nil {
+		return sf.err
+	}
+	if sf.err = sf.seal(sf.buf, true); sf.err != nil {
+		return sf.err
+	}
+	sf.buf = nil
+	sf.err = ErrWriterClosed
+	return nil
+}
+
+type gcmStreamReader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	nonce *gcmStreamNonce
+	in    []byte // cipher text, at most one byte of the next segment
+	out   []byte // plain text which not be read
+	err   error
+}
+
+// Read implement io.Reader
+func (sf *gcmStreamReader) Read(p []byte) (int, error) {
+	for len(sf.out) == 0 {
+		if sf.err != nil {
+			return 0, sf.err
+		}
+		sf.fill()
+	}
+	n := copy(p, sf.out)
+	sf.out = sf.out[n:]
+	return n, nil
+}
+
+func (sf *gcmStreamReader) fill() {
+	if sf.nonce == nil {
+		nonce := &gcmStreamNonce{}
+		if _, err := io.ReadFull(sf.r, nonce.nonce[:gcmStreamPrefixSize]); err != nil {
+			sf.err = err
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				sf.err = ErrCipherTextTooShort
+			}
+			return
+		}
+		sf.nonce = nonce
+	}
+
+	pending := len(sf.in)
+	n, err := io.ReadFull(sf.r, sf.in[pending:cap(sf.in)])
+	sf.in = sf.in[:pending+n]
+	final := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		final = true
+	default:
+		sf.err = err
+		return
+	}
+
+	segment := sf.in
+	if !final {
+		segment = sf.in[:len(sf.in)-1]
+	}
+	nonce, err := sf.nonce.next(final)
+	if err != nil {
+		sf.err = err
+		return
+	}
+	if sf.out, err = sf.aead.Open(sf.out[:0], nonce, segment, nil); err != nil {
+		sf.err = ErrAuthFailed
+		return
+	}
+	if final {
+		sf.err = io.EOF
+		sf.in = sf.in[:0]
+		return
+	}
+	sf.in = append(sf.in[:0], sf.in[len(sf.in)-1])
+}
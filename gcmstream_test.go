@@ -0,0 +1,138 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCMStream(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	segmentSize := 1024
+	overhead := 16
+
+	plainText := make([]byte, 10*segmentSize+100)
+	for i := range plainText {
+		plainText[i] = byte(i * 7)
+	}
+
+	seal := func(t *testing.T, data []byte) []byte {
+		out := &bytes.Buffer{}
+		w, err := NewGCMStreamWriter(out, key, aes.NewCipher, WithSegmentSize(segmentSize))
+		require.NoError(t, err)
+		for len(data) > 0 {
+			n := 333
+			if n > len(data) {
+				n = len(data)
+			}
+			_, err = w.Write(data[:n])
+			require.NoError(t, err)
+			data = data[n:]
+		}
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close())
+		_, err = w.Write([]byte{0x01})
+		require.Equal(t, ErrWriterClosed, err)
+		return out.Bytes()
+	}
+	open := func(stream []byte) ([]byte, error) {
+		r, err := NewGCMStreamReader(iotest.HalfReader(bytes.NewReader(stream)), key, aes.NewCipher, WithSegmentSize(segmentSize))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(r)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, size := range []int{0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 3 * segmentSize, len(plainText)} {
+			stream := seal(t, plainText[:size])
+			segments := (size + segmentSize - 1) / segmentSize
+			if segments == 0 {
+				segments = 1
+			}
+			assert.Equal(t, gcmStreamPrefixSize+size+segments*overhead, len(stream))
+
+			got, err := open(stream)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(plainText[:size], got))
+		}
+	})
+
+	t.Run("default segment size", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		w, err := NewGCMStreamWriter(out, key, aes.NewCipher)
+		require.NoError(t, err)
+		_, err = w.Write(plainText)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		r, err := NewGCMStreamReader(out, key, aes.NewCipher)
+		require.NoError(t, err)
+		got, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("dropped final segment", func(t *testing.T) {
+		stream := seal(t, plainText[:3*segmentSize+100])
+		_, err := open(stream[:len(stream)-(100+overhead)])
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		stream := seal(t, plainText[:3*segmentSize+100])
+		_, err := open(stream[:len(stream)-1])
+		require.Equal(t, ErrAuthFailed, err)
+		_, err = open(stream[:gcmStreamPrefixSize-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = open(stream[:gcmStreamPrefixSize])
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("reordered segments", func(t *testing.T) {
+		stream := seal(t, plainText[:3*segmentSize+100])
+		seg := segmentSize + overhead
+		first := stream[gcmStreamPrefixSize : gcmStreamPrefixSize+seg]
+		second := stream[gcmStreamPrefixSize+seg : gcmStreamPrefixSize+2*seg]
+
+		reordered := append([]byte{}, stream[:gcmStreamPrefixSize]...)
+		reordered = append(reordered, second...)
+		reordered = append(reordered, first...)
+		reordered = append(reordered, stream[gcmStreamPrefixSize+2*seg:]...)
+		_, err := open(reordered)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		stream := seal(t, plainText[:100])
+		stream[gcmStreamPrefixSize] ^= 0x01
+		_, err := open(stream)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewGCMStreamWriter(&bytes.Buffer{}, key, aes.NewCipher, WithSegmentSize(0))
+		require.Equal(t, ErrInvalidSegmentSize, err)
+		_, err = NewGCMStreamReader(&bytes.Buffer{}, key, aes.NewCipher, WithSegmentSize(-1))
+		require.Equal(t, ErrInvalidSegmentSize, err)
+		_, err = NewGCMStreamWriter(&bytes.Buffer{}, key, mockErrorNewCipher)
+		require.Error(t, err)
+		_, err = NewGCMStreamReader(&bytes.Buffer{}, key[:8], des.NewCipher)
+		require.Error(t, err)
+
+		w, err := NewGCMStreamWriter(&bytes.Buffer{}, key, aes.NewCipher, WithRand(bytes.NewReader(nil)))
+		require.NoError(t, err)
+		_, err = w.Write(plainText)
+		require.Error(t, err)
+		require.Error(t, w.Close())
+	})
+}
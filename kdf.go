@@ -0,0 +1,77 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrInvalidKeyLength derived key length must be positive
+var ErrInvalidKeyLength = errors.New("derived key length must be positive")
+
+// DefaultPBKDF2Iterations default pbkdf2 iteration count
+const DefaultPBKDF2Iterations = 10000
+
+// DeriveKeyPBKDF2 derive a key of keyLen bytes from password and salt with pbkdf2,
+// the iterations default DefaultPBKDF2Iterations if iterations <= 0.
+// keyLen should be 16, 24 or 32 bytes for aes-128, aes-192 or aes-256.
+func DeriveKeyPBKDF2(password, salt []byte, iterations, keyLen int, h func() hash.Hash) []byte {
+	if iterations <= 0 {
+		iterations = DefaultPBKDF2Iterations
+	}
+	return pbkdf2.Key(password, salt, iterations, keyLen, h)
+}
+
+// DeriveKeyPBKDF2SHA256 derive a key of keyLen bytes from password and salt with pbkdf2,
+// use DefaultPBKDF2Iterations and sha256.
+func DeriveKeyPBKDF2SHA256(password, salt []byte, keyLen int) []byte {
+	return DeriveKeyPBKDF2(password, salt, DefaultPBKDF2Iterations, keyLen, sha256.New)
+}
+
+// DeriveKeyScrypt derive a key of keyLen bytes from password and salt with scrypt,
+// which is memory hard. N is the CPU/memory cost parameter, must be a power of two greater than 1,
+// r*p must be < 2^30, the recommended parameters for interactive logins as of 2017 are N=32768, r=8 and p=1.
+// it returns scrypt's own error for invalid N, r, p.
+func DeriveKeyScrypt(password, salt []byte, N, r, p, keyLen int) ([]byte, error) { // nolint: gocritic
+	if keyLen <= 0 {
+		return nil, ErrInvalidKeyLength
+	}
+	return scrypt.Key(password, salt, N, r, p, keyLen)
+}
+
+// DeriveKeyArgon2id derive a key of keyLen bytes from password and salt with argon2id.
+// the time parameter specifies the number of passes over the memory and
+// the memory parameter specifies the size of the memory in KiB.
+// the recommended parameters(RFC 9106) are time=1, memory=64*1024(64MB), threads=4 and keyLen=32,
+// the threads can be adjusted to the numbers of available CPUs. salt should be random and at least 16 bytes.
+func DeriveKeyArgon2id(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	return argon2.IDKey(password, salt, time, memory, threads, keyLen)
+}
+
+// EVPBytesToKey derive key and iv from password and salt, compatible with OpenSSL EVP_BytesToKey
+// which use md5 and one iteration, the default of `openssl enc` before OpenSSL 1.1.0 (-md md5).
+// salt is the 8 bytes following the `Salted__` magic header, or nil if no salt.
+// NOTE: it is a weak key derivation, only use it for compatibility.
+func EVPBytesToKey(password, salt []byte, keyLen, ivLen int) (key, iv []byte) {
+	var digest []byte
+
+	buf := make([]byte, 0, keyLen+ivLen+md5.Size)
+	for len(buf) < keyLen+ivLen {
+		h := md5.New()
+		h.Write(digest)   // nolint: errcheck
+		h.Write(password) // nolint: errcheck
+		h.Write(salt)     // nolint: errcheck
+		digest = h.Sum(nil)
+		buf = append(buf, digest...)
+	}
+	return buf[:keyLen], buf[keyLen : keyLen+ivLen]
+}
@@ -0,0 +1,123 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveKeyPBKDF2(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+
+	key := DeriveKeyPBKDF2(password, salt, 1, 32, sha256.New)
+	assert.Equal(t, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b", hex.EncodeToString(key))
+	key = DeriveKeyPBKDF2(password, salt, 4096, 32, sha256.New)
+	assert.Equal(t, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a", hex.EncodeToString(key))
+
+	assert.Equal(t, DeriveKeyPBKDF2(password, salt, DefaultPBKDF2Iterations, 32, sha256.New),
+		DeriveKeyPBKDF2(password, salt, 0, 32, sha256.New))
+
+	for _, keySize := range aesKeySizes {
+		key := DeriveKeyPBKDF2SHA256(password, salt, keySize)
+		require.Len(t, key, keySize)
+		_, err := NewBlockCrypt(key, make([]byte, aes.BlockSize), aes.NewCipher)
+		require.NoError(t, err)
+	}
+}
+
+func TestDeriveKeyScrypt(t *testing.T) {
+	key, err := DeriveKeyScrypt([]byte("password"), []byte("NaCl"), 1024, 8, 16, 32)
+	require.NoError(t, err)
+	assert.Equal(t, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162", hex.EncodeToString(key))
+
+	key1, err := DeriveKeyScrypt([]byte("secret"), []byte("salt"), 16384, 8, 1, 32)
+	require.NoError(t, err)
+	key2, err := DeriveKeyScrypt([]byte("secret"), []byte("salt"), 16384, 8, 1, 32)
+	require.NoError(t, err)
+	require.Len(t, key1, 32)
+	assert.Equal(t, key1, key2)
+
+	plainText := []byte("helloworld,this is golang language. welcome")
+	bc, err := NewBlockCrypt(key1, make([]byte, aes.BlockSize), aes.NewCipher)
+	require.NoError(t, err)
+	cipherText, err := bc.Encrypt(plainText)
+	require.NoError(t, err)
+	bc, err = NewBlockCrypt(key2, make([]byte, aes.BlockSize), aes.NewCipher)
+	require.NoError(t, err)
+	got, err := bc.Decrypt(cipherText)
+	require.NoError(t, err)
+	assert.Equal(t, plainText, got)
+
+	_, err = DeriveKeyScrypt([]byte("secret"), []byte("salt"), 1000, 8, 1, 32)
+	require.EqualError(t, err, "scrypt: N must be > 1 and a power of 2")
+	_, err = DeriveKeyScrypt([]byte("secret"), []byte("salt"), 1024, 8, 1, 0)
+	require.Equal(t, ErrInvalidKeyLength, err)
+}
+
+func TestDeriveKeyArgon2id(t *testing.T) {
+	password, salt := []byte("passphrase"), []byte("0123456789abcdef")
+
+	key := DeriveKeyArgon2id(password, salt, 1, 64*1024, 4, 32)
+	require.Len(t, key, 32)
+	assert.Equal(t, key, DeriveKeyArgon2id(password, salt, 1, 64*1024, 4, 32))
+	assert.NotEqual(t, key, DeriveKeyArgon2id(password, []byte("fedcba9876543210"), 1, 64*1024, 4, 32))
+
+	plainText := []byte("helloworld,this is golang language. welcome")
+	bc, err := NewBlockCryptRandomIV(key, aes.NewCipher)
+	require.NoError(t, err)
+	cipherText, err := bc.Encrypt(plainText)
+	require.NoError(t, err)
+	bc, err = NewBlockCryptRandomIV(DeriveKeyArgon2id(password, salt, 1, 64*1024, 4, 32), aes.NewCipher)
+	require.NoError(t, err)
+	got, err := bc.Decrypt(cipherText)
+	require.NoError(t, err)
+	assert.Equal(t, plainText, got)
+}
+
+func TestEVPBytesToKey(t *testing.T) {
+	// openssl enc -aes-256-cbc -md md5 -pass pass:secret -S 0102030405060708 -P
+	key, iv := EVPBytesToKey([]byte("secret"), []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, 32, 16)
+	assert.Equal(t, "c9e5a1bd216dbe1317e230cef48f38ee7f0e17ad64022144bccec4a1aa2879ab", hex.EncodeToString(key))
+	assert.Equal(t, "e24b32bbbc4ef02ecbcb6576523ad893", hex.EncodeToString(iv))
+
+	tests := []struct {
+		name   string
+		keyLen int
+		blob   string
+		want   string
+	}{
+		{
+			// printf 'helloworld,this is golang language. welcome' | openssl enc -aes-256-cbc -md md5 -pass pass:secret -base64 -A
+			"aes-256-cbc",
+			32,
+			"U2FsdGVkX1+gisnnZWtBzvetJ/EI1YwPUX88900qvT4ZIz3mSwoV2CRsB6Kctvnrvuxvm75fF+WnVHHro9fuvw==",
+			"helloworld,this is golang language. welcome",
+		},
+		{
+			// printf 'hello' | openssl enc -aes-128-cbc -md md5 -pass pass:secret -base64 -A
+			"aes-128-cbc",
+			16,
+			"U2FsdGVkX1/0Unj5qdMu41Dj/HuoBrX5f8oV8vtFA58=",
+			"hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob, err := base64.StdEncoding.DecodeString(tt.blob)
+			require.NoError(t, err)
+			require.Equal(t, "Salted__", string(blob[:8]))
+
+			key, iv := EVPBytesToKey([]byte("secret"), blob[8:16], tt.keyLen, aes.BlockSize)
+			bc, err := NewBlockCrypt(key, iv, aes.NewCipher)
+			require.NoError(t, err)
+			got, err := bc.Decrypt(blob[16:])
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
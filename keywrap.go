@@ -0,0 +1,89 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// error defined
+var (
+	ErrInvalidKeyWrapSize = errors.New("key wrap data length must be a multiple of 8 bytes and at least 16 bytes")
+	ErrKeyWrapIntegrity   = errors.New("key wrap integrity check failed")
+)
+
+// keyWrapIV the default initial value of RFC 3394
+var keyWrapIV = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// WrapKey wrap the key with the key-encryption key, implement AES Key Wrap(RFC 3394),
+// kek must be 16, 24 or 32 bytes, the key length must be a multiple of 8 bytes and at least 16 bytes,
+// otherwise return ErrInvalidKeyWrapSize, the wrapped key is 8 bytes longer than the key.
+func WrapKey(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, ErrInvalidKeyWrapSize
+	}
+	block, err := newBlock(aes.NewCipher, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(key) / 8
+	wrapped := make([]byte, 8+len(key))
+	copy(wrapped, keyWrapIV[:])
+	copy(wrapped[8:], key)
+
+	var b [aes.BlockSize]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b[:8], wrapped[:8])
+			copy(b[8:], wrapped[i*8:])
+			block.Encrypt(b[:], b[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(wrapped[:8], binary.BigEndian.Uint64(b[:8])^t)
+			copy(wrapped[i*8:], b[8:])
+		}
+	}
+	return wrapped, nil
+}
+
+// UnwrapKey unwrap the key wrapped by WrapKey with the key-encryption key,
+// return ErrInvalidKeyWrapSize if the wrapped length is invalid,
+// ErrKeyWrapIntegrity if the wrapped key is tampered or the kek is wrong.
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, ErrInvalidKeyWrapSize
+	}
+	block, err := newBlock(aes.NewCipher, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrapped)/8 - 1
+	var a [8]byte
+	copy(a[:], wrapped[:8])
+	key := append([]byte{}, wrapped[8:]...)
+
+	var b [aes.BlockSize]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a[:])^t)
+			copy(b[8:], key[(i-1)*8:])
+			block.Decrypt(b[:], b[:])
+			copy(a[:], b[:8])
+			copy(key[(i-1)*8:], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a[:], keyWrapIV[:]) != 1 {
+		for i := range key {
+			key[i] = 0
+		}
+		return nil, ErrKeyWrapIntegrity
+	}
+	return key, nil
+}
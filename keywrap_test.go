@@ -0,0 +1,88 @@
+package aesext
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyWrap(t *testing.T) {
+	t.Run("rfc 3394 vectors", func(t *testing.T) {
+		tests := []struct {
+			name, kek, key, want string
+		}{
+			{
+				"4.1 128 bits key with 128 bits kek",
+				"000102030405060708090A0B0C0D0E0F",
+				"00112233445566778899AABBCCDDEEFF",
+				"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+			},
+			{
+				"4.2 128 bits key with 192 bits kek",
+				"000102030405060708090A0B0C0D0E0F1011121314151617",
+				"00112233445566778899AABBCCDDEEFF",
+				"96778B25AE6CA435F92B5B97C050AED2468AB8A17AD84E5D",
+			},
+			{
+				"4.3 128 bits key with 256 bits kek",
+				"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+				"00112233445566778899AABBCCDDEEFF",
+				"64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7",
+			},
+			{
+				"4.6 256 bits key with 256 bits kek",
+				"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+				"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+				"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				kek, _ := hex.DecodeString(tt.kek)
+				key, _ := hex.DecodeString(tt.key)
+
+				wrapped, err := WrapKey(kek, key)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, strings.ToUpper(hex.EncodeToString(wrapped)))
+
+				got, err := UnwrapKey(kek, wrapped)
+				require.NoError(t, err)
+				assert.Equal(t, key, got)
+			})
+		}
+	})
+
+	t.Run("integrity", func(t *testing.T) {
+		kek := make([]byte, 16)
+		wrapped, err := WrapKey(kek, make([]byte, 32))
+		require.NoError(t, err)
+
+		for i := range wrapped {
+			tampered := append([]byte{}, wrapped...)
+			tampered[i] ^= 0x01
+			_, err = UnwrapKey(kek, tampered)
+			require.Equal(t, ErrKeyWrapIntegrity, err)
+		}
+		_, err = UnwrapKey(make([]byte, 24), wrapped)
+		require.Equal(t, ErrKeyWrapIntegrity, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		kek := make([]byte, 16)
+		for _, size := range []int{0, 8, 17, 31} {
+			_, err := WrapKey(kek, make([]byte, size))
+			require.Equal(t, ErrInvalidKeyWrapSize, err)
+		}
+		for _, size := range []int{0, 16, 25} {
+			_, err := UnwrapKey(kek, make([]byte, size))
+			require.Equal(t, ErrInvalidKeyWrapSize, err)
+		}
+		_, err := WrapKey(kek[:10], make([]byte, 16))
+		require.Error(t, err)
+		_, err = UnwrapKey(kek[:10], make([]byte, 24))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// error defined
+var (
+	ErrInvalidMarshalData = errors.New("invalid marshal data")
+	ErrParamsMismatch     = errors.New("parameters mismatch")
+)
+
+// binary marshal format version 1:
+//	version(1) | flags(1) | padding id(1) | key length(2) | block size(2) | max cipher size(8) | iv length(1) | iv
+//	| min pad to(8), only for the WithMinPadTo padding
+const (
+	marshalVersion1        = 0x01
+	marshalHeaderSize      = 1 + 1 + 1 + 2 + 2 + 8 + 1
+	flagRandomIV      byte = 1 << 0
+	flagStream        byte = 1 << 1
+	flagNoPadding     byte = 1 << 2
+	flagIVPrefix      byte = 1 << 3
+)
+
+// padding scheme id, 0 means custom padding, which is not transferred.
+const (
+	paddingCustom byte = iota
+	paddingPKCS7
+	paddingZero
+	paddingX923
+	paddingISO10126
+	paddingISO7816
+	paddingPKCS7ConstantTime
+	paddingMinPadTo
+)
+
+// MarshalBinary implement encoding.BinaryMarshaler, it encodes the non-secret parameters only:
+// the key length, block size, iv, random iv, iv prefix, stream, no padding, max cipher size and the
+// builtin padding scheme, include WithMinPadTo. the key is explicitly excluded, and so is the codec which is a function,
+// so the receiving side creates the crypt with its own key and the same codec, then UnmarshalBinary.
+func (sf *blockBlock) MarshalBinary() ([]byte, error) {
+	var flags byte
+	if sf.randomIV {
+		flags |= flagRandomIV
+	}
+	if sf.stream {
+		flags |= flagStream
+	}
+	if sf.noPadding {
+		flags |= flagNoPadding
+	}
+	if sf.ivPrefix {
+		flags |= flagIVPrefix
+	}
+	var padding byte
+	switch sf.padding.(type) {
+	case PKCS7:
+		padding = paddingPKCS7
+	case Zero:
+		padding = paddingZero
+	case X923:
+		padding = paddingX923
+	case ISO10126:
+		padding = paddingISO10126
+	case ISO7816:
+		padding = paddingISO7816
+	case PKCS7ConstantTime:
+		padding = paddingPKCS7ConstantTime
+	case minPadTo:
+		padding = paddingMinPadTo
+	default:
+		padding = paddingCustom
+	}
+	maxCipherSize := sf.maxCipherSize
+	if maxCipherSize < 0 {
+		maxCipherSize = 0
+	}
+
+	b := make([]byte, marshalHeaderSize, marshalHeaderSize+len(sf.iv))
+	b[0] = marshalVersion1
+	b[1] = flags
+	b[2] = padding
+	binary.BigEndian.PutUint16(b[3:], uint16(sf.keyLen))
+	binary.BigEndian.PutUint16(b[5:], uint16(sf.block.BlockSize()))
+	binary.BigEndian.PutUint64(b[7:], uint64(maxCipherSize))
+	b[15] = byte(len(sf.iv))
+	b = append(b, sf.iv...)
+	if p, ok := sf.padding.(minPadTo); ok {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(p.n))
+		b = append(b, n[:]...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implement encoding.BinaryUnmarshaler, it applies the parameters encoded by MarshalBinary,
+// the key and the codec are kept, return ErrParamsMismatch if the block size, the known key length
+// or the stream mode differ from the crypt, which means a different codec, ErrWeakIV if the iv is all zero
+// with WithStrictIV, ErrUnsupportedVersion if the version is unknown.
+// it is not safe for concurrent use with Encrypt and Decrypt.
+func (sf *blockBlock) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return ErrInvalidMarshalData
+	}
+	if data[0] != marshalVersion1 {
+		return ErrUnsupportedVersion
+	}
+	if len(data) < marshalHeaderSize {
+		return ErrInvalidMarshalData
+	}
+	flags, padding := data[1], data[2]
+	size := marshalHeaderSize + int(data[15])
+	if padding == paddingMinPadTo {
+		size += 8
+	}
+	if len(data) != size {
+		return ErrInvalidMarshalData
+	}
+	keyLen := int(binary.BigEndian.Uint16(data[3:]))
+	blockSize := int(binary.BigEndian.Uint16(data[5:]))
+	maxCipherSize := binary.BigEndian.Uint64(data[7:])
+	iv := data[marshalHeaderSize : marshalHeaderSize+int(data[15])]
+
+	stream := flags&flagStream != 0
+	if blockSize != sf.block.BlockSize() || (keyLen != 0 && sf.keyLen != 0 && keyLen != sf.keyLen) ||
+		stream != sf.stream {
+		return ErrParamsMismatch
+	}
+	randomIV := flags&flagRandomIV != 0
+	if (randomIV && len(iv) != 0) || (!randomIV && len(iv) != blockSize) || maxCipherSize > 1<<31-1 {
+		return ErrInvalidMarshalData
+	}
+	if !randomIV {
+		if err := sf.checkIV(iv); err != nil {
+			return err
+		}
+	}
+	minPadSize := 0
+	switch padding {
+	case paddingCustom: // keep the receiver's own
+		minPadSize = sf.minPadTo
+	case paddingPKCS7:
+		sf.padding = PKCS7{}
+	case paddingZero:
+		sf.padding = Zero{}
+	case paddingX923:
+		sf.padding = X923{}
+	case paddingISO10126:
+		sf.padding = ISO10126{sf.rand}
+	case paddingISO7816:
+		sf.padding = ISO7816{}
+	case paddingPKCS7ConstantTime:
+		sf.padding = PKCS7ConstantTime{}
+	case paddingMinPadTo:
+		n := binary.BigEndian.Uint64(data[size-8:])
+		if n == 0 || n > 1<<31-1 {
+			return ErrInvalidMarshalData
+		}
+		minPadSize = int(n)
+		sf.padding = minPadTo{minPadSize}
+	default:
+		return ErrInvalidMarshalData
+	}
+	sf.randomIV = randomIV
+	sf.noPadding = flags&flagNoPadding != 0
+	sf.ivPrefix = flags&flagIVPrefix != 0
+	sf.maxCipherSize = int(maxCipherSize)
+	sf.minPadTo = minPadSize
+	if randomIV {
+		sf.iv = nil
+	} else {
+		sf.iv = append(sf.iv[:0], iv...)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"crypto/sha256"
+	"encoding"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryMarshal(t *testing.T) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("reconstruct", func(t *testing.T) {
+		sender, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher,
+			WithPadding(X923{}), WithMaxCipherSize(1024))
+		require.NoError(t, err)
+		cipherText, err := sender.Encrypt(plainText)
+		require.NoError(t, err)
+
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+		// the key is excluded
+		assert.False(t, bytes.Contains(data, key[:16]))
+		assert.True(t, bytes.Contains(data, iv[:aes.BlockSize]))
+
+		// the receiver supplies its own key
+		receiver, err := NewBlockCryptRandomIV(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+		require.NoError(t, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		got, err := receiver.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+		assert.IsType(t, X923{}, receiver.(*blockBlock).padding)
+		assert.Equal(t, 1024, receiver.(*blockBlock).maxCipherSize)
+
+		again, err := receiver.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+	})
+
+	t.Run("random iv", func(t *testing.T) {
+		sender, err := NewBlockCryptRandomIV(key[:32], aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := sender.Encrypt(plainText)
+		require.NoError(t, err)
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+
+		receiver, err := NewBlockCrypt(key[:32], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		require.NoError(t, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		got, err := receiver.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("iv prefix", func(t *testing.T) {
+		sender, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher, WithIVPrefix())
+		require.NoError(t, err)
+		cipherText, err := sender.Encrypt(plainText)
+		require.NoError(t, err)
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+
+		receiver, err := NewBlockCrypt(key[:16], make([]byte, aes.BlockSize), aes.NewCipher)
+		require.NoError(t, err)
+		require.NoError(t, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		got, err := receiver.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		sender, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+
+		keyLen, err := NewBlockCrypt(key[:32], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		require.Equal(t, ErrParamsMismatch, keyLen.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		blockSize, err := NewBlockCrypt(key[:8], iv[:des.BlockSize], des.NewCipher)
+		require.NoError(t, err)
+		require.Equal(t, ErrParamsMismatch, blockSize.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+	})
+
+	t.Run("codec mismatch", func(t *testing.T) {
+		ctr, err := NewCTRCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		data, err := ctr.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+
+		cbc, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		require.Equal(t, ErrParamsMismatch, cbc.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		randomIV, err := NewBlockCryptRandomIV(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+		require.Equal(t, ErrParamsMismatch, randomIV.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		// the receivers are untouched, never panic
+		for _, bc := range []BlockCrypt{cbc, randomIV} {
+			cipherText, err := bc.Encrypt([]byte("hello"))
+			require.NoError(t, err)
+			got, err := bc.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("hello"), got)
+		}
+
+		// the reverse
+		data, err = cbc.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, ErrParamsMismatch, ctr.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+	})
+
+	t.Run("min pad to", func(t *testing.T) {
+		sender, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher, WithMinPadTo(300))
+		require.NoError(t, err)
+		cipherText, err := sender.Encrypt(plainText)
+		require.NoError(t, err)
+		require.Len(t, cipherText, 304)
+		// exactly sized
+		assert.Equal(t, len(cipherText), cap(cipherText))
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+
+		receiver, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		require.NoError(t, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		got, err := receiver.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+		again, err := receiver.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, cipherText, again)
+
+		again, err = receiver.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+
+		require.Equal(t, ErrInvalidMarshalData, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(data[:len(data)-8]))
+		zero := append([]byte{}, data...)
+		copy(zero[len(zero)-8:], make([]byte, 8))
+		require.Equal(t, ErrInvalidMarshalData, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(zero))
+	})
+
+	t.Run("strict iv", func(t *testing.T) {
+		sender, err := NewBlockCrypt(key[:16], make([]byte, aes.BlockSize), aes.NewCipher)
+		require.NoError(t, err)
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+
+		receiver, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher, WithStrictIV())
+		require.NoError(t, err)
+		require.Equal(t, ErrWeakIV, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		assert.Equal(t, iv[:aes.BlockSize], receiver.(IVAccessor).IV())
+	})
+
+	t.Run("fixed iv into random iv", func(t *testing.T) {
+		sender, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+
+		receiver, err := NewBlockCryptRandomIV(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+		require.NoError(t, receiver.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+		cipherText, err := receiver.Encrypt([]byte("hello"))
+		require.NoError(t, err)
+		want, err := sender.Encrypt([]byte("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, want, cipherText)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		sender, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		data, err := sender.(encoding.BinaryMarshaler).MarshalBinary()
+		require.NoError(t, err)
+		receiver := sender.Clone().(encoding.BinaryUnmarshaler)
+
+		require.Equal(t, ErrInvalidMarshalData, receiver.UnmarshalBinary(nil))
+		require.Equal(t, ErrInvalidMarshalData, receiver.UnmarshalBinary(data[:len(data)-1]))
+		unknown := append([]byte{}, data...)
+		unknown[0] = 0x02
+		require.Equal(t, ErrUnsupportedVersion, receiver.UnmarshalBinary(unknown))
+		padding := append([]byte{}, data...)
+		padding[2] = 0xff
+		require.Equal(t, ErrInvalidMarshalData, receiver.UnmarshalBinary(padding))
+	})
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"errors"
+)
+
+// error defined
+var (
+	ErrNoBlockCrypt  = errors.New("no block crypt given")
+	ErrNoAEADCrypt   = errors.New("no aead crypt given")
+	ErrNoMatchingKey = errors.New("no key decrypts the cipher text")
+)
+
+// NewMultiKeyDecryptor new a BlockCrypt for the key rotation, Encrypt always use the first crypt,
+// which holds the current key, Decrypt tries each crypt in turn and returns the first success,
+// it moves to the next crypt only on the wrong key errors, that is the padding error, such as ErrInvalidPadding
+// and ErrUnPaddingOutOfRange, or the authentication error, ErrMACMismatch and ErrAuthFailed, any other error,
+// such as ErrInputNotMultipleBlocks for the truncated input, is returned as is.
+// return ErrNoMatchingKey if all fail with the wrong key errors, ErrNoBlockCrypt if no crypt given.
+// so the cipher text encrypted with the old keys is still readable during the migration.
+// it is safe with the authenticated crypt, such as NewEncryptThenMAC, which fails on the tag verification.
+// NOTE: with unauthenticated cbc, a wrong key still unpads successfully in about 1/256 of the cases,
+// then the garbage is returned as plain text. and the padding check of each trial is a padding oracle,
+// the time taken even reveals which key matched, never expose it to the untrusted party.
+func NewMultiKeyDecryptor(crypts ...BlockCrypt) (BlockCrypt, error) {
+	if len(crypts) == 0 {
+		return nil, ErrNoBlockCrypt
+	}
+	return &multiKeyDecryptor{append([]BlockCrypt{}, crypts...)}, nil
+}
+
+type multiKeyDecryptor struct {
+	crypts []BlockCrypt
+}
+
+func (sf *multiKeyDecryptor) BlockSize() int {
+	return sf.crypts[0].BlockSize()
+}
+
+// Encrypt encrypt with the first crypt
+func (sf *multiKeyDecryptor) Encrypt(plainText []byte) ([]byte, error) {
+	return sf.crypts[0].Encrypt(plainText)
+}
+
+// EncryptTo encrypt with the first crypt
+func (sf *multiKeyDecryptor) EncryptTo(dst, plainText []byte) ([]byte, error) {
+	return sf.crypts[0].EncryptTo(dst, plainText)
+}
+
+// Decrypt try each crypt in turn
+func (sf *multiKeyDecryptor) Decrypt(cipherText []byte) ([]byte, error) {
+	for _, bc := range sf.crypts {
+		plainText, err := bc.Decrypt(cipherText)
+		if err == nil {
+			return plainText, nil
+		}
+		if !isWrongKey(err) {
+			return nil, err
+		}
+	}
+	return nil, ErrNoMatchingKey
+}
+
+// DecryptTo try each crypt in turn, the cipher text must be kept intact for the next trial,
+// so it decrypts to a fresh buffer, then appends to dst.
+func (sf *multiKeyDecryptor) DecryptTo(dst, cipherText []byte) ([]byte, error) {
+	plainText, err := sf.Decrypt(cipherText)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, plainText...), nil
+}
+
+// Clone clone each crypt
+func (sf *multiKeyDecryptor) Clone() BlockCrypt {
+	crypts := make([]BlockCrypt, 0, len(sf.crypts))
+	for _, bc := range sf.crypts {
+		crypts = append(crypts, bc.Clone())
+	}
+	return &multiKeyDecryptor{crypts}
+}
+
+// isWrongKey reports whether the decryption error means a wrong key, so the next key is worth a trial.
+func isWrongKey(err error) bool {
+	return errors.Is(err, ErrInvalidPadding) || errors.Is(err, ErrUnPaddingOutOfRange) ||
+		errors.Is(err, ErrMACMismatch) || errors.Is(err, ErrAuthFailed)
+}
+
+// NewMultiKeyAEAD new an AEADCrypt for the key rotation, the same as NewMultiKeyDecryptor,
+// the seal methods always use the first aead, the open methods try each aead in turn and rely on
+// the tag verification, only ErrAuthFailed moves to the next aead, any other error is returned as is,
+// return ErrAuthFailed if all fail.
+// all the aeads must have the same NonceSize and Overhead, otherwise return ErrParamsMismatch,
+// ErrNoAEADCrypt if no aead given.
+// NOTE: OpenInPlace keeps a copy of the cipher text to restore it for the next trial, so it allocates.
+func NewMultiKeyAEAD(aeads ...AEADCrypt) (AEADCrypt, error) {
+	if len(aeads) == 0 {
+		return nil, ErrNoAEADCrypt
+	}
+	for _, ad := range aeads[1:] {
+		if ad.NonceSize() != aeads[0].NonceSize() || ad.Overhead() != aeads[0].Overhead() {
+			return nil, ErrParamsMismatch
+		}
+	}
+	return &multiKeyAEAD{append([]AEADCrypt{}, aeads...)}, nil
+}
+
+type multiKeyAEAD struct {
+	aeads []AEADCrypt
+}
+
+func (sf *multiKeyAEAD) NonceSize() int {
+	return sf.aeads[0].NonceSize()
+}
+
+func (sf *multiKeyAEAD) Overhead() int {
+	return sf.aeads[0].Overhead()
+}
+
+// Seal seal with the first aead
+func (sf *multiKeyAEAD) Seal(dst, nonce, plainText, additionalData []byte) []byte {
+	return sf.aeads[0].Seal(dst, nonce, plainText, additionalData)
+}
+
+// SealRandom seal with the first aead
+func (sf *multiKeyAEAD) SealRandom(plainText, additionalData []byte) ([]byte, error) {
+	return sf.aeads[0].SealRandom(plainText, additionalData)
+}
+
+// SealSequence seal with the first aead
+func (sf *multiKeyAEAD) SealSequence(seq *NonceSequence, plainText, additionalData []byte) ([]byte, error) {
+	return sf.aeads[0].SealSequence(seq, plainText, additionalData)
+}
+
+// SealDetached seal with the first aead
+func (sf *multiKeyAEAD) SealDetached(nonce, plainText, additionalData []byte) (cipherText, tag []byte) {
+	return sf.aeads[0].SealDetached(nonce, plainText, additionalData)
+}
+
+// SplitIV split the prepended nonce
+func (sf *multiKeyAEAD) SplitIV(blob []byte) ([]byte, []byte, error) {
+	return sf.aeads[0].SplitIV(blob)
+}
+
+// Open try each aead in turn, the cipher text must be kept intact for the next trial,
+// so it opens to a fresh buffer, then appends to dst.
+func (sf *multiKeyAEAD) Open(dst, nonce, cipherText, additionalData []byte) ([]byte, error) {
+	plainText, err := sf.open(func(ad AEADCrypt) ([]byte, error) {
+		return ad.Open(nil, nonce, cipherText, additionalData)
+	})
+	if err != nil || dst == nil {
+		return plainText, err
+	}
+	return append(dst, plainText...), nil
+}
+
+// OpenRandom try each aead in turn
+func (sf *multiKeyAEAD) OpenRandom(blob, additionalData []byte) ([]byte, error) {
+	return sf.open(func(ad AEADCrypt) ([]byte, error) {
+		return ad.OpenRandom(blob, additionalData)
+	})
+}
+
+// OpenDetached try each aead in turn
+func (sf *multiKeyAEAD) OpenDetached(nonce, cipherText, tag, additionalData []byte) ([]byte, error) {
+	return sf.open(func(ad AEADCrypt) ([]byte, error) {
+		return ad.OpenDetached(nonce, cipherText, tag, additionalData)
+	})
+}
+
+// OpenInPlace try each aead in turn, restore the clobbered cipher text before each trial.
+func (sf *multiKeyAEAD) OpenInPlace(nonce, cipherText, additionalData []byte) ([]byte, error) {
+	var backup []byte
+	if len(sf.aeads) > 1 {
+		backup = append([]byte{}, cipherText...)
+	}
+	trial := 0
+	return sf.open(func(ad AEADCrypt) ([]byte, error) {
+		if trial > 0 {
+			copy(cipherText, backup)
+		}
+		trial++
+		return ad.OpenInPlace(nonce, cipherText, additionalData)
+	})
+}
+
+func (sf *multiKeyAEAD) open(fn func(ad AEADCrypt) ([]byte, error)) ([]byte, error) {
+	for _, ad := range sf.aeads {
+		plainText, err := fn(ad)
+		if !errors.Is(err, ErrAuthFailed) {
+			return plainText, err
+		}
+	}
+	return nil, ErrAuthFailed
+}
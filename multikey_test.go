@@ -0,0 +1,174 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiKeyDecryptor(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+	macKey := []byte("0123456789abcdef0123456789abcdef")
+	newETM := func(key string) BlockCrypt {
+		bc, err := NewEncryptThenMAC([]byte(key), macKey, aes.NewCipher)
+		require.NoError(t, err)
+		return bc
+	}
+	current, old, other := newETM("current_key_16b!"), newETM("old_key_16bytes!"), newETM("other_key_16byte")
+
+	bc, err := NewMultiKeyDecryptor(current, old)
+	require.NoError(t, err)
+	assert.Equal(t, aes.BlockSize, bc.BlockSize())
+
+	t.Run("encrypt with the first", func(t *testing.T) {
+		cipherText, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+		got, err := current.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		cipherText, err = bc.EncryptTo([]byte("prefix"), plainText)
+		require.NoError(t, err)
+		got, err = current.Decrypt(cipherText[len("prefix"):])
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("decrypt with any", func(t *testing.T) {
+		for _, crypt := range []BlockCrypt{current, old} {
+			cipherText, err := crypt.Encrypt(plainText)
+			require.NoError(t, err)
+			got, err := bc.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+
+			// reuse the cipher text's storage
+			got, err = bc.Clone().DecryptTo(cipherText[:0], cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		cipherText, err := other.Encrypt(plainText)
+		require.NoError(t, err)
+		_, err = bc.Decrypt(cipherText)
+		require.Equal(t, ErrNoMatchingKey, err)
+		_, err = bc.DecryptTo(nil, cipherText)
+		require.Equal(t, ErrNoMatchingKey, err)
+	})
+
+	t.Run("cbc", func(t *testing.T) {
+		key, iv := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+		oldCBC, err := NewAESCBC([]byte("old_key_16bytes!"), iv)
+		require.NoError(t, err)
+		currentCBC, err := NewAESCBC(key, iv)
+		require.NoError(t, err)
+		bc, err := NewMultiKeyDecryptor(currentCBC, oldCBC)
+		require.NoError(t, err)
+
+		cipherText, err := oldCBC.Encrypt(plainText)
+		require.NoError(t, err)
+		got, err := bc.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		// the truncated input is not a wrong key
+		_, err = bc.Decrypt(cipherText[:len(cipherText)-1])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+
+	t.Run("not wrong key error", func(t *testing.T) {
+		cipherText, err := old.Encrypt(plainText)
+		require.NoError(t, err)
+		_, err = bc.Decrypt(cipherText[:10])
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewMultiKeyDecryptor()
+		require.Equal(t, ErrNoBlockCrypt, err)
+	})
+}
+
+func TestMultiKeyAEAD(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+	additionalData := []byte("additional data")
+	newAEAD := func(key string) AEADCrypt {
+		ad, err := NewAEAD([]byte(key), aes.NewCipher)
+		require.NoError(t, err)
+		return ad
+	}
+	current, old, other := newAEAD("current_key_16b!"), newAEAD("old_key_16bytes!"), newAEAD("other_key_16byte")
+
+	ad, err := NewMultiKeyAEAD(current, old)
+	require.NoError(t, err)
+	assert.Equal(t, current.NonceSize(), ad.NonceSize())
+	assert.Equal(t, current.Overhead(), ad.Overhead())
+
+	t.Run("seal with the first", func(t *testing.T) {
+		blob, err := ad.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		got, err := current.OpenRandom(blob, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		nonce := make([]byte, ad.NonceSize())
+		cipherText, tag := ad.SealDetached(nonce, plainText, additionalData)
+		got, err = current.OpenDetached(nonce, cipherText, tag, additionalData)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("open with any", func(t *testing.T) {
+		nonce := make([]byte, ad.NonceSize())
+		for _, aead := range []AEADCrypt{current, old} {
+			blob, err := aead.SealRandom(plainText, additionalData)
+			require.NoError(t, err)
+			got, err := ad.OpenRandom(blob, additionalData)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+
+			sealed := aead.Seal(nil, nonce, plainText, additionalData)
+			got, err = ad.Open([]byte("prefix"), nonce, sealed, additionalData)
+			require.NoError(t, err)
+			assert.Equal(t, append([]byte("prefix"), plainText...), got)
+
+			cipherText, tag := aead.SealDetached(nonce, plainText, additionalData)
+			got, err = ad.OpenDetached(nonce, cipherText, tag, additionalData)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+
+			// the cipher text is restored for the next trial
+			got, err = ad.OpenInPlace(nonce, sealed, additionalData)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		blob, err := other.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		_, err = ad.OpenRandom(blob, additionalData)
+		require.Equal(t, ErrAuthFailed, err)
+		_, err = ad.OpenInPlace(nonce, other.Seal(nil, nonce, plainText, additionalData), additionalData)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("not auth error", func(t *testing.T) {
+		blob, err := old.SealRandom(plainText, additionalData)
+		require.NoError(t, err)
+		_, err = ad.OpenRandom(blob[:ad.NonceSize()-1], additionalData)
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = ad.OpenRandom(blob[:ad.NonceSize()+ad.Overhead()-1], additionalData)
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewMultiKeyAEAD()
+		require.Equal(t, ErrNoAEADCrypt, err)
+
+		shortTag, err := NewAEAD([]byte("old_key_16bytes!"), aes.NewCipher, WithGCMTagSize(12))
+		require.NoError(t, err)
+		_, err = NewMultiKeyAEAD(current, shortTag)
+		require.Equal(t, ErrParamsMismatch, err)
+	})
+}
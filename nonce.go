@@ -0,0 +1,76 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrNonceExhausted nonce sequence counter would wrap
+var ErrNonceExhausted = errors.New("nonce sequence exhausted")
+
+// NonceSequence produce monotonically increasing nonce, the nonce is prefix + big-endian counter,
+// the counter start from 0 and occupy the low nonceSize - prefixSize bytes(up to 8 bytes),
+// Next return ErrNonceExhausted when the counter would wrap, so the nonce never be reused.
+// it is safe for concurrent use by multiple goroutines.
+// NOTE: the sequence must not be shared by different keys' state which may restart,
+// such as persist the counter across process restarts.
+type NonceSequence struct {
+	mu          sync.Mutex
+	prefix      []byte
+	counterSize int
+	counter     uint64
+	exhausted   bool
+}
+
+// NewNonceSequence new nonce sequence with nonceSize, prefixSize and custom option,
+// the prefix is filled from random source(see WithRand), prefixSize 0 means no prefix.
+// the counter size nonceSize - prefixSize must be positive.
+// option support:
+//      WithRand
+func NewNonceSequence(nonceSize, prefixSize int, opts ...Option) (*NonceSequence, error) {
+	if prefixSize < 0 || nonceSize-prefixSize <= 0 {
+		return nil, ErrInvalidNonceSize
+	}
+	prefix := make([]byte, prefixSize)
+	if _, err := io.ReadFull(newConfig(opts...).rand, prefix); err != nil {
+		return nil, err
+	}
+	return &NonceSequence{prefix: prefix, counterSize: nonceSize - prefixSize}, nil
+}
+
+// NonceSize returns the nonce size
+func (sf *NonceSequence) NonceSize() int {
+	return len(sf.prefix) + sf.counterSize
+}
+
+// Next returns the next nonce, return ErrNonceExhausted when the counter would wrap.
+func (sf *NonceSequence) Next() ([]byte, error) {
+	nonce := make([]byte, sf.NonceSize())
+	if err := sf.next(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// next fill the next nonce to dst, which length must be NonceSize().
+func (sf *NonceSequence) next(dst []byte) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.exhausted {
+		return ErrNonceExhausted
+	}
+	copy(dst, sf.prefix)
+	for i, n := len(dst)-1, sf.counter; i >= len(sf.prefix) && n > 0; i, n = i-1, n>>8 {
+		dst[i] = byte(n)
+	}
+	sf.counter++
+	if sf.counterSize < 8 && sf.counter == 1<<(8*uint(sf.counterSize)) || sf.counter == 0 {
+		sf.exhausted = true
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceSequence(t *testing.T) {
+	t.Run("monotonically increasing", func(t *testing.T) {
+		seq, err := NewNonceSequence(12, 4, WithRand(bytes.NewReader([]byte{0xa1, 0xa2, 0xa3, 0xa4})))
+		require.NoError(t, err)
+		assert.Equal(t, 12, seq.NonceSize())
+
+		for i := 0; i < 300; i++ {
+			nonce, err := seq.Next()
+			require.NoError(t, err)
+			want := []byte{0xa1, 0xa2, 0xa3, 0xa4, 0, 0, 0, 0, 0, 0, byte(i >> 8), byte(i)}
+			require.Equal(t, want, nonce)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		seq, err := NewNonceSequence(2, 1)
+		require.NoError(t, err)
+		seen := make(map[string]bool)
+		for i := 0; i < 256; i++ {
+			nonce, err := seq.Next()
+			require.NoError(t, err)
+			require.False(t, seen[string(nonce)])
+			seen[string(nonce)] = true
+		}
+		_, err = seq.Next()
+		require.Equal(t, ErrNonceExhausted, err)
+		_, err = seq.Next()
+		require.Equal(t, ErrNonceExhausted, err)
+	})
+
+	t.Run("concurrent unique", func(t *testing.T) {
+		seq, err := NewNonceSequence(12, 0)
+		require.NoError(t, err)
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					nonce, err := seq.Next()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					mu.Lock()
+					seen[string(nonce)] = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, seen, 800)
+	})
+
+	t.Run("seal sequence", func(t *testing.T) {
+		key := sha256.Sum256([]byte("secret_key"))
+		plainText := []byte("helloworld,this is golang language. welcome")
+		ad, err := NewAEAD(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+		seq, err := NewNonceSequence(ad.NonceSize(), 4)
+		require.NoError(t, err)
+
+		blob1, err := ad.SealSequence(seq, plainText, nil)
+		require.NoError(t, err)
+		blob2, err := ad.SealSequence(seq, plainText, nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, blob1[:12], blob2[:12])
+		for _, blob := range [][]byte{blob1, blob2} {
+			got, err := ad.OpenRandom(blob, nil)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+
+		other, err := NewNonceSequence(16, 4)
+		require.NoError(t, err)
+		_, err = ad.SealSequence(other, plainText, nil)
+		require.Equal(t, ErrInvalidNonceSize, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewNonceSequence(12, 12)
+		require.Equal(t, ErrInvalidNonceSize, err)
+		_, err = NewNonceSequence(12, -1)
+		require.Equal(t, ErrInvalidNonceSize, err)
+		// random source exhausted
+		_, err = NewNonceSequence(12, 4, WithRand(bytes.NewReader(nil)))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+)
+
+// OFBCrypt the crypt created by NewOFBCrypt implement it, callers type-assert it,
+// it exposes the key stream for sparse access.
+type OFBCrypt interface {
+	// KeystreamAt returns the first n bytes of the key stream, xor the region [off, off+len)
+	// of the cipher text with the same region of it to decrypt the region alone.
+	// NOTE: each ofb key stream block is the encryption of the previous one, so unlike
+	// CTRCrypt.StreamAt it can not seek, the cost is linear in n, cache it for repeated access.
+	// it panics if n is negative.
+	KeystreamAt(n int) []byte
+}
+
+// NewOFBCrypt new ofb mode with newCipher, key, iv and custom option.
+// ofb is a stream mode, the cipher text length equal plain text length, no padding.
+// the returned crypt implement OFBCrypt, there is no cfb counterpart, the cfb key stream depends on the cipher text.
+func NewOFBCrypt(key, iv []byte, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (BlockCrypt, error) {
+	bc, err := NewBlockCrypt(key, iv, newCipher,
+		append([]Option{WithStreamCodec(cipher.NewOFB, cipher.NewOFB)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &ofbCrypt{bc.(*blockBlock)}, nil
+}
+
+type ofbCrypt struct {
+	*blockBlock
+}
+
+// Clone clone
+func (sf *ofbCrypt) Clone() BlockCrypt {
+	return &ofbCrypt{sf.blockBlock.Clone().(*blockBlock)}
+}
+
+// KeystreamAt returns n bytes key stream
+func (sf *ofbCrypt) KeystreamAt(n int) []byte {
+	if n < 0 {
+		panic("aesext: negative length given to KeystreamAt")
+	}
+	keystream := make([]byte, n)
+	cipher.NewOFB(sf.block, sf.iv).XORKeyStream(keystream, keystream)
+	return keystream
+}
@@ -0,0 +1,64 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOFBCrypt(t *testing.T) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	plainText := make([]byte, 4096)
+	for i := range plainText {
+		plainText[i] = byte(i * 7)
+	}
+
+	bc, err := NewOFBCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+	require.NoError(t, err)
+	ofb, ok := bc.(OFBCrypt)
+	require.True(t, ok)
+	cipherText, err := bc.Encrypt(plainText)
+	require.NoError(t, err)
+	require.Len(t, cipherText, len(plainText))
+
+	t.Run("keystream at", func(t *testing.T) {
+		keystream := ofb.KeystreamAt(len(cipherText))
+		require.Len(t, keystream, len(cipherText))
+		for _, offset := range []int{0, 15, 16, 1000, 4080} {
+			got := make([]byte, 16)
+			for i := range got {
+				got[i] = cipherText[offset+i] ^ keystream[offset+i]
+			}
+			assert.Equal(t, plainText[offset:offset+16], got, offset)
+		}
+		assert.Equal(t, keystream[:1000], ofb.KeystreamAt(1000))
+		assert.Empty(t, ofb.KeystreamAt(0))
+	})
+
+	t.Run("same as ofb stream codec", func(t *testing.T) {
+		cloned := bc.Clone()
+		_, ok := cloned.(OFBCrypt)
+		require.True(t, ok)
+		got, err := cloned.Decrypt(cipherText)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+
+		got, err = ioutil.ReadAll(NewDecryptReader(bytes.NewReader(cipherText), bc))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("negative length", func(t *testing.T) {
+		require.Panics(t, func() { ofb.KeystreamAt(-1) })
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewOFBCrypt(key[:16], iv[:8], aes.NewCipher)
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+	})
+}
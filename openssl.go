@@ -0,0 +1,136 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+// openssl enc salted header
+const (
+	openSSLMagic    = "Salted__"
+	openSSLSaltSize = 8
+)
+
+// ErrInvalidOpenSSLHeader cipher text not start with openssl `Salted__` header
+var ErrInvalidOpenSSLHeader = errors.New("invalid openssl salted header")
+
+// NewOpenSSLCrypt new crypt compatible with `openssl enc -md md5` using a password,
+// with keyLen, newCipher and custom option, keyLen is the cipher key length, such as aes-256 is 32.
+// Encrypt generate a random salt for each message, derive key and iv with EVPBytesToKey,
+// return `Salted__` + 8 bytes salt + cipher text.
+// Decrypt parse the header, re-derive key and iv, then return the plain text.
+// such as aes-256-cbc:
+//      Encrypt output can be decrypted by: openssl enc -d -aes-256-cbc -md md5 -pass pass:password
+//      Decrypt input can be encrypted by: openssl enc -aes-256-cbc -md md5 -pass pass:password
+func NewOpenSSLCrypt(password []byte, keyLen int, newCipher func(key []byte) (cipher.Block, error), opts ...Option) (BlockCrypt, error) {
+	block, err := newBlock(newCipher, make([]byte, keyLen))
+	if err != nil {
+		return nil, err
+	}
+	return &openSSLCrypt{
+		password:  append([]byte{}, password...),
+		keyLen:    keyLen,
+		blockSize: block.BlockSize(),
+		newCipher: newCipher,
+		opts:      opts,
+		rand:      newConfig(opts...).rand,
+	}, nil
+}
+
+type openSSLCrypt struct {
+	password  []byte
+	keyLen    int
+	blockSize int
+	newCipher func(key []byte) (cipher.Block, error)
+	opts      []Option
+	rand      io.Reader
+}
+
+func (sf *openSSLCrypt) BlockSize() int {
+	return sf.blockSize
+}
+
+// Encrypt encrypt
+func (sf *openSSLCrypt) Encrypt(plainText []byte) ([]byte, error) {
+	header := make([]byte, len(openSSLMagic)+openSSLSaltSize)
+	copy(header, openSSLMagic)
+	salt := header[len(openSSLMagic):]
+	if _, err := io.ReadFull(sf.rand, salt); err != nil {
+		return nil, err
+	}
+	bc, err := sf.blockCrypt(salt)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := bc.Encrypt(plainText)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, cipherText...), nil
+}
+
+// EncryptTo encrypt
+func (sf *openSSLCrypt) EncryptTo(dst, plainText []byte) ([]byte, error) {
+	cipherText, err := sf.Encrypt(plainText)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, cipherText...), nil
+}
+
+// Decrypt decrypt
+func (sf *openSSLCrypt) Decrypt(cipherText []byte) ([]byte, error) {
+	headerSize := len(openSSLMagic) + openSSLSaltSize
+	if len(cipherText) < headerSize {
+		return nil, ErrCipherTextTooShort
+	}
+	if !bytes.Equal(cipherText[:len(openSSLMagic)], []byte(openSSLMagic)) {
+		return nil, ErrInvalidOpenSSLHeader
+	}
+	bc, err := sf.blockCrypt(cipherText[len(openSSLMagic):headerSize])
+	if err != nil {
+		return nil, err
+	}
+	return bc.Decrypt(cipherText[headerSize:])
+}
+
+// DecryptTo decrypt
+func (sf *openSSLCrypt) DecryptTo(dst, cipherText []byte) ([]byte, error) {
+	plainText, err := sf.Decrypt(cipherText)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, plainText...), nil
+}
+
+// Clone clone
+func (sf *openSSLCrypt) Clone() BlockCrypt {
+	return &openSSLCrypt{
+		password:  append([]byte{}, sf.password...),
+		keyLen:    sf.keyLen,
+		blockSize: sf.blockSize,
+		newCipher: sf.newCipher,
+		opts:      sf.opts,
+		rand:      sf.rand,
+	}
+}
+
+// Close zero the password, it implement io.Closer.
+// using the BlockCrypt after Close is undefined.
+func (sf *openSSLCrypt) Close() error {
+	for i := range sf.password {
+		sf.password[i] = 0
+	}
+	return nil
+}
+
+func (sf *openSSLCrypt) blockCrypt(salt []byte) (BlockCrypt, error) {
+	key, iv := EVPBytesToKey(sf.password, salt, sf.keyLen, sf.blockSize)
+	return NewBlockCrypt(key, iv, sf.newCipher, sf.opts...)
+}
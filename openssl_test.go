@@ -0,0 +1,99 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenSSLCrypt(t *testing.T) {
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	t.Run("decrypt openssl blob", func(t *testing.T) {
+		// printf 'helloworld,this is golang language. welcome' | openssl enc -aes-256-cbc -md md5 -pass pass:secret -base64 -A
+		blob, err := base64.StdEncoding.DecodeString("U2FsdGVkX1+gisnnZWtBzvetJ/EI1YwPUX88900qvT4ZIz3mSwoV2CRsB6Kctvnrvuxvm75fF+WnVHHro9fuvw==")
+		require.NoError(t, err)
+
+		bc, err := NewOpenSSLCrypt([]byte("secret"), 32, aes.NewCipher)
+		require.NoError(t, err)
+		assert.Equal(t, aes.BlockSize, bc.BlockSize())
+
+		got, err := bc.Decrypt(blob)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("encrypt", func(t *testing.T) {
+		salt := []byte{0xa0, 0x8a, 0xc9, 0xe7, 0x65, 0x6b, 0x41, 0xce}
+		bc, err := NewOpenSSLCrypt([]byte("secret"), 32, aes.NewCipher, WithRand(bytes.NewReader(salt)))
+		require.NoError(t, err)
+
+		blob, err := bc.Encrypt(plainText)
+		require.NoError(t, err)
+		assert.Equal(t, "U2FsdGVkX1+gisnnZWtBzvetJ/EI1YwPUX88900qvT4ZIz3mSwoV2CRsB6Kctvnrvuxvm75fF+WnVHHro9fuvw==",
+			base64.StdEncoding.EncodeToString(blob))
+
+		// random source exhausted
+		_, err = bc.Encrypt(plainText)
+		require.Error(t, err)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, keySize := range aesKeySizes {
+			bc, err := NewOpenSSLCrypt([]byte("secret"), keySize, aes.NewCipher)
+			require.NoError(t, err)
+
+			blob1, err := bc.Encrypt(plainText)
+			require.NoError(t, err)
+			blob2, err := bc.Encrypt(plainText)
+			require.NoError(t, err)
+			assert.NotEqual(t, blob1, blob2)
+
+			for _, blob := range [][]byte{blob1, blob2} {
+				got, err := bc.Decrypt(blob)
+				require.NoError(t, err)
+				assert.Equal(t, plainText, got)
+			}
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		password := []byte("secret")
+		bc, err := NewOpenSSLCrypt(password, 32, aes.NewCipher)
+		require.NoError(t, err)
+		require.NoError(t, bc.(io.Closer).Close())
+		assert.Equal(t, make([]byte, 6), bc.(*openSSLCrypt).password)
+		assert.Equal(t, []byte("secret"), password)
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		bc, err := NewOpenSSLCrypt([]byte("secret"), 32, aes.NewCipher)
+		require.NoError(t, err)
+		cloned := bc.Clone()
+		require.NoError(t, bc.(io.Closer).Close())
+
+		blob, err := cloned.Encrypt(plainText)
+		require.NoError(t, err)
+		got, err := cloned.Decrypt(blob)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		bc, err := NewOpenSSLCrypt([]byte("secret"), 32, aes.NewCipher)
+		require.NoError(t, err)
+
+		_, err = bc.Decrypt([]byte("Salted__"))
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = bc.Decrypt([]byte("Unsalted0123456701234567"))
+		require.Equal(t, ErrInvalidOpenSSLHeader, err)
+
+		_, err = NewOpenSSLCrypt([]byte("secret"), 20, aes.NewCipher)
+		require.Error(t, err)
+	})
+}
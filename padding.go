@@ -0,0 +1,334 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+)
+
+// Padding padding scheme interface
+type Padding interface {
+	// Pad pads data to a multiple of blockSize.
+	Pad(data []byte, blockSize int) []byte
+	// UnPad removes the padding from data.
+	UnPad(data []byte) ([]byte, error)
+}
+
+// pad appends the padding, unlike Padding.Pad, the builtin ISO10126 padding returns the random source error.
+func pad(p Padding, data []byte, blockSize int) ([]byte, error) {
+	if v, ok := p.(ISO10126); ok {
+		return iso10126Padding(data, blockSize, v.Rand)
+	}
+	return p.Pad(data, blockSize), nil
+}
+
+// unPad removes the padding, the builtin pkcs padding also reject the padding length
+// which exceeds blockSize, it is impossible for the valid padding.
+// the custom padding, even if it embeds PKCS7, is called as it is.
+func unPad(p Padding, data []byte, blockSize int) ([]byte, error) {
+	switch p.(type) {
+	case PKCS7:
+		return pcksUnPadding(data, blockSize)
+	case PKCS7ConstantTime:
+		return pcksUnPaddingConstantTime(data, blockSize)
+	default:
+		return p.UnPad(data)
+	}
+}
+
+// PKCS7 PKCS#5和PKCS#7 padding scheme
+type PKCS7 struct{}
+
+// Pad implement Padding, it appends the padding to data, which may reuse the data's spare capacity.
+func (PKCS7) Pad(data []byte, blockSize int) []byte { return pkcsPadding(data, blockSize) }
+
+// UnPad implement Padding
+func (PKCS7) UnPad(data []byte) ([]byte, error) { return PCKSUnPadding(data) }
+
+// PKCS7ConstantTime PKCS#5和PKCS#7 padding scheme, UnPad with PCKSUnPaddingConstantTime,
+// use it for the decryption paths which handle attacker-controlled cipher text.
+type PKCS7ConstantTime struct{}
+
+// Pad implement Padding, the same as PKCS7.
+func (PKCS7ConstantTime) Pad(data []byte, blockSize int) []byte { return pkcsPadding(data, blockSize) }
+
+// UnPad implement Padding
+func (PKCS7ConstantTime) UnPad(data []byte) ([]byte, error) { return PCKSUnPaddingConstantTime(data) }
+
+// Zero zero padding scheme, see ZeroPadding and ZeroUnPadding.
+type Zero struct{}
+
+// Pad implement Padding
+func (Zero) Pad(data []byte, blockSize int) []byte { return ZeroPadding(data, blockSize) }
+
+// UnPad implement Padding
+func (Zero) UnPad(data []byte) ([]byte, error) { return ZeroUnPadding(data) }
+
+// X923 ANSI X9.23 padding scheme, see X923Padding and X923UnPadding.
+type X923 struct{}
+
+// Pad implement Padding
+func (X923) Pad(data []byte, blockSize int) []byte { return X923Padding(data, blockSize) }
+
+// UnPad implement Padding
+func (X923) UnPad(data []byte) ([]byte, error) { return X923UnPadding(data) }
+
+// ISO10126 ISO 10126 padding scheme, see ISO10126Padding and ISO10126UnPadding.
+type ISO10126 struct {
+	// Rand random source, default crypto/rand.Reader if nil.
+	Rand io.Reader
+}
+
+// Pad implement Padding, see ISO10126Padding for the random source failure,
+// the BlockCrypt created by this package returns the error instead.
+func (sf ISO10126) Pad(data []byte, blockSize int) []byte {
+	return ISO10126Padding(data, blockSize, sf.Rand)
+}
+
+// UnPad implement Padding
+func (ISO10126) UnPad(data []byte) ([]byte, error) { return ISO10126UnPadding(data) }
+
+// ISO7816 ISO/IEC 7816-4 padding scheme, see ISO7816Padding and ISO7816UnPadding.
+type ISO7816 struct{}
+
+// Pad implement Padding
+func (ISO7816) Pad(data []byte, blockSize int) []byte { return ISO7816Padding(data, blockSize) }
+
+// UnPad implement Padding
+func (ISO7816) UnPad(data []byte) ([]byte, error) { return ISO7816UnPadding(data) }
+
+// minPadTo length hiding padding scheme, see WithMinPadTo.
+type minPadTo struct {
+	n int
+}
+
+// minPadToTrailerSize zero fill | length(8) | 0x00
+const minPadToTrailerSize = 8 + 1
+
+// Pad implement Padding
+func (sf minPadTo) Pad(data []byte, blockSize int) []byte {
+	length := len(data)
+	target := (sf.n + blockSize - 1) / blockSize * blockSize
+	padded := (length/blockSize + 1) * blockSize
+	if padded < target {
+		padded = target
+	}
+	if padSize := padded - length; padSize <= 255 {
+		for i := 0; i < padSize; i++ {
+			data = append(data, byte(padSize))
+		}
+		return data
+	}
+	// padded - length > 255, so there is enough room for the trailer
+	data = append(data, make([]byte, padded-length-minPadToTrailerSize)...)
+	var trailer [minPadToTrailerSize]byte
+	binary.BigEndian.PutUint64(trailer[:8], uint64(length))
+	return append(data, trailer[:]...)
+}
+
+// UnPad implement Padding
+func (minPadTo) UnPad(data []byte) ([]byte, error) {
+	length := len(data)
+	if length == 0 || data[length-1] != 0x00 {
+		return PCKSUnPadding(data)
+	}
+	if length < minPadToTrailerSize {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	size := binary.BigEndian.Uint64(data[length-minPadToTrailerSize:])
+	if size > uint64(length-minPadToTrailerSize) {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	for _, v := range data[size : length-minPadToTrailerSize] {
+		if v != 0x00 {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return data[:size], nil
+}
+
+// PCKSPadding PKCS#5和PKCS#7 填充
+// it always returns a fresh slice, never writes into the origData's backing array.
+func PCKSPadding(origData []byte, blockSize int) []byte {
+	padSize := blockSize - len(origData)%blockSize
+	return pkcsPadding(append(make([]byte, 0, len(origData)+padSize), origData...), blockSize)
+}
+
+// pkcsPadding append the padding to origData
+func pkcsPadding(origData []byte, blockSize int) []byte {
+	padSize := blockSize - len(origData)%blockSize
+	for i := 0; i < padSize; i++ {
+		origData = append(origData, byte(padSize))
+	}
+	return origData
+}
+
+// PCKSUnPadding PKCS#5和PKCS#7 解填充
+// the block size is unknown, so the padding length is only checked against the data length,
+// the crypt with PKCS7 padding checks it against the block size too.
+func PCKSUnPadding(origData []byte) ([]byte, error) {
+	return pcksUnPadding(origData, 255)
+}
+
+// pcksUnPadding the padding length must not exceed maxPadSize.
+func pcksUnPadding(origData []byte, maxPadSize int) ([]byte, error) {
+	length := len(origData)
+	if length == 0 {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	unPadSize := int(origData[length-1])
+	if unPadSize == 0 || unPadSize > length || unPadSize > maxPadSize {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	for _, v := range origData[length-unPadSize:] {
+		if int(v) != unPadSize {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return origData[:(length - unPadSize)], nil
+}
+
+// PCKSUnPaddingConstantTime PKCS#5和PKCS#7 constant time 解填充.
+// PCKSUnPadding branches on the padding length and returns early at the first bad byte,
+// so its running time and error reveal the padding length and the position of the bad byte,
+// which is a padding oracle. this variant always inspects the last min(len, 255) bytes
+// with no branch or memory access depending on the content, and returns the same ErrInvalidPadding
+// for every invalid padding, including the out of range padding length.
+// the timing only depends on len(origData), which is public. it does not close the
+// side channel of the caller, such as returning distinct errors for bad padding and bad mac.
+func PCKSUnPaddingConstantTime(origData []byte) ([]byte, error) {
+	return pcksUnPaddingConstantTime(origData, 255)
+}
+
+// pcksUnPaddingConstantTime the padding length must not exceed maxPadSize, which is public.
+func pcksUnPaddingConstantTime(origData []byte, maxPadSize int) ([]byte, error) {
+	length := len(origData)
+	if length == 0 {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	unPadSize := int(origData[length-1])
+	good := subtle.ConstantTimeLessOrEq(1, unPadSize) & subtle.ConstantTimeLessOrEq(unPadSize, length) &
+		subtle.ConstantTimeLessOrEq(unPadSize, maxPadSize)
+	toCheck := 255
+	if toCheck > length {
+		toCheck = length
+	}
+	for i := 1; i <= toCheck; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, unPadSize)
+		equal := subtle.ConstantTimeByteEq(origData[length-i], byte(unPadSize))
+		good &= subtle.ConstantTimeSelect(inPadding, equal, 1)
+	}
+	if good != 1 {
+		return nil, ErrInvalidPadding
+	}
+	return origData[:(length - unPadSize)], nil
+}
+
+// ZeroPadding 0x00 填充, 已对齐块大小时不填充.
+// NOTE: zero padding is ambiguous, if the plain text legitimately ends in 0x00 bytes,
+// ZeroUnPadding will strip them too, only use it for text data or interoperate with legacy system.
+func ZeroPadding(origData []byte, blockSize int) []byte {
+	padSize := (blockSize - len(origData)%blockSize) % blockSize
+	return append(origData, make([]byte, padSize)...)
+}
+
+// ZeroUnPadding 0x00 解填充, 去除尾部所有0x00, 返回新的切片, 不修改原数据.
+func ZeroUnPadding(origData []byte) ([]byte, error) {
+	length := len(origData)
+	for length > 0 && origData[length-1] == 0x00 {
+		length--
+	}
+	return append([]byte{}, origData[:length]...), nil
+}
+
+// X923Padding ANSI X9.23 填充, 填充0x00, 最后一个字节为填充长度
+func X923Padding(origData []byte, blockSize int) []byte {
+	padSize := blockSize - len(origData)%blockSize
+	padText := make([]byte, padSize)
+	padText[padSize-1] = byte(padSize)
+	return append(origData, padText...)
+}
+
+// X923UnPadding ANSI X9.23 解填充, 校验填充字节必须为0x00
+func X923UnPadding(origData []byte) ([]byte, error) {
+	length := len(origData)
+	if length == 0 {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	unPadSize := int(origData[length-1])
+	if unPadSize == 0 || unPadSize > length {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	for _, v := range origData[length-unPadSize : length-1] {
+		if v != 0x00 {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return origData[:(length - unPadSize)], nil
+}
+
+// ISO10126Padding ISO 10126 填充, 填充随机字节, 最后一个字节为填充长度.
+// random source default crypto/rand.Reader if nil, the random bytes carry no meaning,
+// so if the random source failed, the rest bytes keep 0x00 silently, it is still a valid padding,
+// but no longer random, the BlockCrypt created by this package returns the error instead.
+func ISO10126Padding(origData []byte, blockSize int, random io.Reader) []byte {
+	padded, _ := iso10126Padding(origData, blockSize, random)
+	return padded
+}
+
+// iso10126Padding same as ISO10126Padding, but returns the random source error.
+func iso10126Padding(origData []byte, blockSize int, random io.Reader) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	padSize := blockSize - len(origData)%blockSize
+	padText := make([]byte, padSize)
+	_, err := io.ReadFull(random, padText[:padSize-1])
+	padText[padSize-1] = byte(padSize)
+	return append(origData, padText...), err
+}
+
+// ISO10126UnPadding ISO 10126 解填充, 只读取最后一个字节作为填充长度
+func ISO10126UnPadding(origData []byte) ([]byte, error) {
+	length := len(origData)
+	if length == 0 {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	unPadSize := int(origData[length-1])
+	if unPadSize == 0 || unPadSize > length {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	return origData[:(length - unPadSize)], nil
+}
+
+// ISO7816Padding ISO/IEC 7816-4 填充, 填充一个0x80, 然后填充0x00至块大小.
+// it is unambiguous, unlike zero padding.
+func ISO7816Padding(origData []byte, blockSize int) []byte {
+	padSize := blockSize - len(origData)%blockSize
+	padText := make([]byte, padSize)
+	padText[0] = 0x80
+	return append(origData, padText...)
+}
+
+// ISO7816UnPadding ISO/IEC 7816-4 解填充, 从尾部跳过0x00直到0x80标记, 未找到标记返回错误
+func ISO7816UnPadding(origData []byte) ([]byte, error) {
+	length := len(origData)
+	if length == 0 {
+		return nil, ErrUnPaddingOutOfRange
+	}
+	for i := length - 1; i >= 0; i-- {
+		switch origData[i] {
+		case 0x80:
+			return origData[:i], nil
+		case 0x00:
+		default:
+			return nil, ErrInvalidPadding
+		}
+	}
+	return nil, ErrInvalidPadding
+}
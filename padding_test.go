@@ -0,0 +1,381 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPadding struct {
+	PKCS7
+	pad, unPad int
+}
+
+func (sf *mockPadding) Pad(data []byte, blockSize int) []byte {
+	sf.pad++
+	return sf.PKCS7.Pad(data, blockSize)
+}
+
+func (sf *mockPadding) UnPad(data []byte) ([]byte, error) {
+	sf.unPad++
+	return sf.PKCS7.UnPad(data)
+}
+
+func TestPKCS7(t *testing.T) {
+	for length := 0; length <= 2*aes.BlockSize; length++ {
+		data := make([]byte, length)
+		padded := PKCS7{}.Pad(data, aes.BlockSize)
+		require.Zero(t, len(padded)%aes.BlockSize)
+		require.Greater(t, len(padded), length)
+
+		got, err := PKCS7{}.UnPad(padded)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+	}
+	_, err := PKCS7{}.UnPad(nil)
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = PCKSUnPadding([]byte{0x01, 0x00})
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = PCKSUnPadding([]byte{0x01, 0x03})
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = PCKSUnPadding([]byte{0x01, 0x02, 0x04, 0x03, 0x04})
+	require.Equal(t, ErrInvalidPadding, err)
+}
+
+func TestPCKSUnPaddingConstantTime(t *testing.T) {
+	for _, blockSize := range []int{aes.BlockSize, 255} {
+		for length := 0; length <= 2*blockSize; length++ {
+			data := make([]byte, length)
+			padded := PKCS7ConstantTime{}.Pad(data, blockSize)
+			got, err := PKCS7ConstantTime{}.UnPad(padded)
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+		}
+	}
+
+	// the same result as PCKSUnPadding for all the valid padding
+	for _, data := range [][]byte{
+		{0x01},
+		{0x01, 0x02, 0x02},
+		{0xff, 0x03, 0x03, 0x03},
+	} {
+		want, err := PCKSUnPadding(data)
+		require.NoError(t, err)
+		got, err := PCKSUnPaddingConstantTime(data)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	_, err := PCKSUnPaddingConstantTime(nil)
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	for _, data := range [][]byte{
+		{0x01, 0x00},
+		{0x01, 0x03},
+		{0x01, 0x02, 0x04, 0x03, 0x04},
+		{0x03, 0x02, 0x02, 0x03, 0x03},
+	} {
+		_, err = PCKSUnPaddingConstantTime(data)
+		require.Equal(t, ErrInvalidPadding, err, data)
+	}
+}
+
+func TestPCKSUnPaddingBlockSize(t *testing.T) {
+	key, iv := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	// 17 bytes 0x11 padding is longer than a block, which is impossible for the valid padding
+	data := bytes.Repeat([]byte{0x11}, 2*aes.BlockSize)
+	raw, err := NewBlockCrypt(key, iv, aes.NewCipher, WithNoPadding())
+	require.NoError(t, err)
+	cipherText, err := raw.Encrypt(data)
+	require.NoError(t, err)
+
+	got, err := PCKSUnPadding(data)
+	require.NoError(t, err)
+	require.Len(t, got, 2*aes.BlockSize-0x11)
+
+	for _, tt := range []struct {
+		padding Padding
+		wantErr error
+	}{
+		{PKCS7{}, ErrUnPaddingOutOfRange},
+		{PKCS7ConstantTime{}, ErrInvalidPadding},
+	} {
+		blk, err := NewBlockCrypt(key, iv, aes.NewCipher, WithPadding(tt.padding))
+		require.NoError(t, err)
+		_, err = blk.Decrypt(cipherText)
+		require.Equal(t, tt.wantErr, err)
+		_, err = blk.(Padder).UnPad(data)
+		require.Equal(t, tt.wantErr, err)
+		_, valid, err := blk.(PaddingVerifier).DecryptVerify(cipherText)
+		require.NoError(t, err)
+		require.False(t, valid)
+
+		// a full padding block is still valid
+		got, err := blk.(Padder).UnPad(bytes.Repeat([]byte{aes.BlockSize}, aes.BlockSize))
+		require.NoError(t, err)
+		require.Empty(t, got)
+	}
+}
+
+func TestWithMinPadTo(t *testing.T) {
+	key, iv := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	raw, err := NewBlockCrypt(key, iv, aes.NewCipher, WithNoPadding())
+	require.NoError(t, err)
+
+	for _, n := range []int{100, 250, 256, 1000} {
+		blk, err := NewBlockCrypt(key, iv, aes.NewCipher, WithMinPadTo(n), WithPadding(Zero{}))
+		require.NoError(t, err)
+		for length := 0; length <= 1100; length += 7 {
+			plainText := bytes.Repeat([]byte{0x5a}, length)
+			cipherText, err := blk.Encrypt(plainText)
+			require.NoError(t, err)
+			want := (length/aes.BlockSize + 1) * aes.BlockSize
+			if target := (n + aes.BlockSize - 1) / aes.BlockSize * aes.BlockSize; want < target {
+				want = target
+			}
+			require.Len(t, cipherText, want, "n %d length %d", n, length)
+
+			got, err := blk.Decrypt(cipherText)
+			require.NoError(t, err)
+			require.Equal(t, plainText, got)
+
+			padded, err := raw.Decrypt(cipherText)
+			require.NoError(t, err)
+			if padSize := want - length; padSize <= 255 {
+				// still PKCS#7
+				got, err = PCKSUnPadding(padded)
+				require.NoError(t, err)
+				require.Equal(t, plainText, got)
+			} else {
+				require.Equal(t, byte(0x00), padded[len(padded)-1])
+			}
+		}
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		p := minPadTo{1000}
+		padded := p.Pad([]byte("hello"), aes.BlockSize)
+		require.Len(t, padded, 1008)
+		_, err := p.UnPad(nil)
+		require.Equal(t, ErrUnPaddingOutOfRange, err)
+		_, err = p.UnPad(padded[len(padded)-minPadToTrailerSize+1:])
+		require.Equal(t, ErrUnPaddingOutOfRange, err)
+
+		bad := append([]byte{}, padded...)
+		bad[len(bad)-minPadToTrailerSize] = 0xff // length too large
+		_, err = p.UnPad(bad)
+		require.Equal(t, ErrUnPaddingOutOfRange, err)
+		bad = append([]byte{}, padded...)
+		bad[10] = 0x01 // non-zero fill
+		_, err = p.UnPad(bad)
+		require.Equal(t, ErrInvalidPadding, err)
+		_, err = p.UnPad([]byte{0x01, 0x02, 0x02, 0x03})
+		require.Equal(t, ErrInvalidPadding, err)
+	})
+
+	t.Run("streaming not supported", func(t *testing.T) {
+		blk, err := NewBlockCrypt(key, iv, aes.NewCipher, WithMinPadTo(256))
+		require.NoError(t, err)
+		_, err = NewEncryptWriter(&bytes.Buffer{}, blk).Write([]byte("hello"))
+		require.Equal(t, ErrStreamNotSupported, err)
+		_, err = NewDecryptReader(bytes.NewReader(make([]byte, 256)), blk).Read(make([]byte, 16))
+		require.Equal(t, ErrStreamNotSupported, err)
+
+		ctr, err := NewCTRCrypt(key, iv, aes.NewCipher, WithMinPadTo(256))
+		require.NoError(t, err)
+		cipherText, err := ctr.Encrypt([]byte("hello"))
+		require.NoError(t, err)
+		require.Len(t, cipherText, 5)
+		_, err = NewEncryptWriter(&bytes.Buffer{}, ctr).Write([]byte("hello"))
+		require.NoError(t, err)
+	})
+}
+
+func TestPCKSPaddingFreshCopy(t *testing.T) {
+	backing := make([]byte, 3, 64)
+	copy(backing, []byte{0x01, 0x02, 0x03})
+	padded := PCKSPadding(backing, 8)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x05, 0x05, 0x05, 0x05, 0x05}, padded)
+	// never write into the caller's backing array
+	require.Equal(t, make([]byte, 5), backing[3:8])
+	padded[0] = 0xff
+	require.Equal(t, byte(0x01), backing[0])
+}
+
+func TestZero(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"empty", []byte{}, []byte{}},
+		{"not aligned", []byte{0x01, 0x02, 0x03}, []byte{0x01, 0x02, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"aligned", []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			padded := Zero{}.Pad(tt.data, 8)
+			require.Equal(t, tt.want, padded)
+			got, err := Zero{}.UnPad(padded)
+			require.NoError(t, err)
+			require.Equal(t, tt.data, got)
+		})
+	}
+
+	t.Run("trailing zero ambiguity", func(t *testing.T) {
+		got, err := ZeroUnPadding(ZeroPadding([]byte{0x01, 0x00}, 8))
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x01}, got)
+	})
+	t.Run("not mutate", func(t *testing.T) {
+		padded := []byte{0x01, 0x02, 0x00, 0x00}
+		got, err := ZeroUnPadding(padded)
+		require.NoError(t, err)
+		got = append(got, 0xff)
+		require.Equal(t, []byte{0x01, 0x02, 0xff}, got)
+		require.Equal(t, []byte{0x01, 0x02, 0x00, 0x00}, padded)
+	})
+}
+
+func TestX923(t *testing.T) {
+	padded := X923Padding([]byte{0x01, 0x02, 0x03}, 8)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x00, 0x00, 0x00, 0x00, 0x05}, padded)
+	got, err := X923{}.UnPad(padded)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, got)
+
+	padded = X923{}.Pad([]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, 8)
+	require.Len(t, padded, 16)
+	got, err = X923UnPadding(padded)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, got)
+
+	_, err = X923UnPadding(nil)
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = X923UnPadding([]byte{0x01, 0x00})
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = X923UnPadding([]byte{0x01, 0x03})
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = X923UnPadding([]byte{0x01, 0x02, 0x01, 0x00, 0x04})
+	require.Equal(t, ErrInvalidPadding, err)
+}
+
+func TestISO10126(t *testing.T) {
+	random := bytes.NewReader([]byte{0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7})
+	padded := ISO10126Padding([]byte{0x01, 0x02, 0x03}, 8, random)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0xa1, 0xa2, 0xa3, 0xa4, 0x05}, padded)
+	got, err := ISO10126UnPadding(padded)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, got)
+
+	for length := 0; length <= 2*aes.BlockSize; length++ {
+		data := make([]byte, length)
+		padded := ISO10126{}.Pad(data, aes.BlockSize)
+		require.Zero(t, len(padded)%aes.BlockSize)
+
+		got, err := ISO10126{}.UnPad(padded)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+	}
+
+	_, err = ISO10126UnPadding(nil)
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = ISO10126UnPadding([]byte{0x01, 0x00})
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = ISO10126UnPadding([]byte{0x01, 0x03})
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+}
+
+func TestISO7816(t *testing.T) {
+	padded := ISO7816Padding([]byte{0x01, 0x02, 0x03}, 8)
+	require.Equal(t, []byte{0x01, 0x02, 0x03, 0x80, 0x00, 0x00, 0x00, 0x00}, padded)
+	got, err := ISO7816{}.UnPad(padded)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01, 0x02, 0x03}, got)
+
+	// trailing 0x00 and 0x80 in data are kept
+	for _, data := range [][]byte{
+		{},
+		{0x01, 0x00},
+		{0x01, 0x80},
+		{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07},
+		{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x00},
+	} {
+		padded := ISO7816{}.Pad(data, 8)
+		require.Zero(t, len(padded)%8)
+		require.Greater(t, len(padded), len(data))
+		got, err := ISO7816UnPadding(padded)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+	}
+
+	_, err = ISO7816UnPadding(nil)
+	require.Equal(t, ErrUnPaddingOutOfRange, err)
+	_, err = ISO7816UnPadding([]byte{0x00, 0x00})
+	require.Equal(t, ErrInvalidPadding, err)
+	_, err = ISO7816UnPadding([]byte{0x80, 0x01, 0x00})
+	require.Equal(t, ErrInvalidPadding, err)
+}
+
+func TestISO10126WithRand(t *testing.T) {
+	key, iv := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	plainText := []byte("hello")
+
+	random := bytes.Repeat([]byte{0xa5}, aes.BlockSize)
+	blk, err := NewBlockCrypt(key, iv, aes.NewCipher,
+		WithPadding(ISO10126{}), WithRand(bytes.NewReader(random)))
+	require.NoError(t, err)
+	cipherText, err := blk.Encrypt(plainText)
+	require.NoError(t, err)
+
+	raw, err := NewBlockCrypt(key, iv, aes.NewCipher, WithNoPadding())
+	require.NoError(t, err)
+	got, err := raw.Decrypt(cipherText)
+	require.NoError(t, err)
+	want := append(append([]byte{}, plainText...), random[:aes.BlockSize-len(plainText)-1]...)
+	assert.Equal(t, append(want, byte(aes.BlockSize-len(plainText))), got)
+
+	t.Run("random source failure", func(t *testing.T) {
+		blk, err := NewBlockCrypt(key, iv, aes.NewCipher,
+			WithPadding(ISO10126{}), WithRand(bytes.NewReader(nil)))
+		require.NoError(t, err)
+		_, err = blk.Encrypt(plainText)
+		require.Equal(t, io.EOF, err)
+		_, err = blk.(IVAccessor).EncryptWithIV(iv, plainText)
+		require.Equal(t, io.EOF, err)
+
+		enc, err := NewSequentialEncrypter(blk)
+		require.NoError(t, err)
+		_, err = enc.Encrypt(plainText)
+		require.Equal(t, io.EOF, err)
+
+		w := NewEncryptWriter(&bytes.Buffer{}, blk)
+		_, err = w.Write(plainText)
+		require.NoError(t, err)
+		require.Equal(t, io.EOF, w.Close())
+
+		// the function keeps 0x00
+		padded := ISO10126Padding([]byte{0x01}, 4, bytes.NewReader(nil))
+		assert.Equal(t, []byte{0x01, 0x00, 0x00, 0x03}, padded)
+	})
+}
+
+func TestWithPadding(t *testing.T) {
+	key, iv := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	plainText := []byte("helloworld,this is golang language. welcome")
+
+	p := &mockPadding{}
+	blk, err := NewBlockCrypt(key, iv, aes.NewCipher, WithPadding(p))
+	require.NoError(t, err)
+
+	cipherText, err := blk.Encrypt(plainText)
+	require.NoError(t, err)
+	got, err := blk.Decrypt(cipherText)
+	require.NoError(t, err)
+	assert.Equal(t, plainText, got)
+	assert.Equal(t, 1, p.pad)
+	assert.Equal(t, 1, p.unPad)
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// error defined
+var (
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	ErrInvalidKDFParams   = errors.New("invalid kdf parameters")
+)
+
+// password blob format version 1:
+//	version(1) | argon2id time(4) | memory(4) | threads(1) | salt(16) | nonce(12) | aes-256-gcm cipher text with tag
+// the header before the nonce is authenticated as the additional data.
+const (
+	passwordVersion1    = 0x01
+	passwordSaltSize    = 16
+	passwordKeySize     = 32
+	passwordHeaderSize  = 1 + 4 + 4 + 1 + passwordSaltSize
+	defaultArgon2Time   = 1
+	defaultArgon2Memory = 64 * 1024
+	defaultArgon2Thread = 4
+	// the version 1 maximum parameters, the blob is untrusted, so the kdf cost must be bounded
+	// before the tag can be checked.
+	passwordMaxTime    = 4
+	passwordMaxMemory  = 2 * defaultArgon2Memory // 128MB in KiB
+	passwordMaxThreads = 16
+)
+
+// WithArgon2id option argon2id parameters for SealWithPassword, default time=1, memory=64*1024(64MB), threads=4,
+// see DeriveKeyArgon2id. the parameters are stored in the blob, so OpenWithPassword needs no option.
+// the maximum is time=4, memory=128*1024(128MB), threads=16, SealWithPassword and OpenWithPassword
+// return ErrInvalidKDFParams beyond it.
+func WithArgon2id(time, memory uint32, threads uint8) Option {
+	return func(c *config) {
+		c.argon2Time, c.argon2Memory, c.argon2Threads = time, memory, threads
+	}
+}
+
+// SealWithPassword encrypt plain text with password, return a self-describing blob,
+// which contains a versioned header, the argon2id parameters, salt, nonce, cipher text and tag,
+// the key is derived by argon2id with a fresh random salt, and encrypted with aes-256-gcm.
+// option support:
+//      WithArgon2id
+//      WithRand
+func SealWithPassword(password, plainText []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts...)
+	if !validArgon2Params(c.argon2Time, c.argon2Memory, c.argon2Threads) {
+		return nil, ErrInvalidKDFParams
+	}
+	header := make([]byte, passwordHeaderSize)
+	header[0] = passwordVersion1
+	binary.BigEndian.PutUint32(header[1:], c.argon2Time)
+	binary.BigEndian.PutUint32(header[5:], c.argon2Memory)
+	header[9] = c.argon2Threads
+	salt := header[10:]
+	if _, err := io.ReadFull(c.rand, salt); err != nil {
+		return nil, err
+	}
+
+	key := DeriveKeyArgon2id(password, salt, c.argon2Time, c.argon2Memory, c.argon2Threads, passwordKeySize)
+	ad, err := NewAEAD(key, aes.NewCipher, WithRand(c.rand))
+	if err != nil {
+		return nil, err
+	}
+	blob, err := ad.SealRandom(plainText, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, blob...), nil
+}
+
+// OpenWithPassword decrypt the blob sealed by SealWithPassword, return ErrUnsupportedVersion
+// if the version is unknown, ErrInvalidKDFParams if the argon2id parameters exceed the maximum,
+// which is checked before deriving the key, ErrAuthFailed if the password is wrong or the blob is tampered.
+func OpenWithPassword(password, blob []byte) ([]byte, error) {
+	if len(blob) == 0 {
+		return nil, ErrCipherTextTooShort
+	}
+	if blob[0] != passwordVersion1 {
+		return nil, ErrUnsupportedVersion
+	}
+	if len(blob) < passwordHeaderSize {
+		return nil, ErrCipherTextTooShort
+	}
+	header := blob[:passwordHeaderSize]
+	time := binary.BigEndian.Uint32(header[1:])
+	memory := binary.BigEndian.Uint32(header[5:])
+	threads := header[9]
+	if !validArgon2Params(time, memory, threads) {
+		return nil, ErrInvalidKDFParams
+	}
+
+	key := DeriveKeyArgon2id(password, header[10:], time, memory, threads, passwordKeySize)
+	ad, err := NewAEAD(key, aes.NewCipher)
+	if err != nil {
+		return nil, err
+	}
+	return ad.OpenRandom(blob[passwordHeaderSize:], header)
+}
+
+// validArgon2Params reports whether the argon2id parameters are within the version 1 bounds.
+func validArgon2Params(time, memory uint32, threads uint8) bool {
+	return time > 0 && time <= passwordMaxTime &&
+		memory > 0 && memory <= passwordMaxMemory &&
+		threads > 0 && threads <= passwordMaxThreads
+}
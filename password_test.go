@@ -0,0 +1,123 @@
+package aesext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealWithPassword(t *testing.T) {
+	password := []byte("secret")
+	plainText := []byte("helloworld,this is golang language. welcome")
+	fast := WithArgon2id(1, 1024, 1)
+
+	t.Run("default", func(t *testing.T) {
+		blob, err := SealWithPassword(password, plainText)
+		require.NoError(t, err)
+		assert.Equal(t, passwordHeaderSize+12+len(plainText)+16, len(blob))
+		assert.Equal(t, byte(passwordVersion1), blob[0])
+
+		got, err := OpenWithPassword(password, blob)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("random salt and nonce", func(t *testing.T) {
+		blob1, err := SealWithPassword(password, plainText, fast)
+		require.NoError(t, err)
+		blob2, err := SealWithPassword(password, plainText, fast)
+		require.NoError(t, err)
+		assert.NotEqual(t, blob1, blob2)
+
+		for _, blob := range [][]byte{blob1, blob2} {
+			got, err := OpenWithPassword(password, blob)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+		}
+	})
+
+	t.Run("deterministic with rand", func(t *testing.T) {
+		random := bytes.Repeat([]byte{0x01}, passwordSaltSize+12)
+		blob1, err := SealWithPassword(password, plainText, fast, WithRand(bytes.NewReader(random)))
+		require.NoError(t, err)
+		blob2, err := SealWithPassword(password, plainText, fast, WithRand(bytes.NewReader(random)))
+		require.NoError(t, err)
+		assert.Equal(t, blob1, blob2)
+	})
+
+	t.Run("auth failed", func(t *testing.T) {
+		blob, err := SealWithPassword(password, plainText, fast)
+		require.NoError(t, err)
+
+		_, err = OpenWithPassword([]byte("wrong"), blob)
+		require.Equal(t, ErrAuthFailed, err)
+
+		// the header is authenticated
+		tampered := append([]byte{}, blob...)
+		tampered[passwordHeaderSize-1] ^= 0x01
+		_, err = OpenWithPassword(password, tampered)
+		require.Equal(t, ErrAuthFailed, err)
+		tampered = append([]byte{}, blob...)
+		tampered[len(tampered)-1] ^= 0x01
+		_, err = OpenWithPassword(password, tampered)
+		require.Equal(t, ErrAuthFailed, err)
+	})
+
+	t.Run("invalid blob", func(t *testing.T) {
+		blob, err := SealWithPassword(password, plainText, fast)
+		require.NoError(t, err)
+
+		_, err = OpenWithPassword(password, nil)
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = OpenWithPassword(password, blob[:passwordHeaderSize-1])
+		require.Equal(t, ErrCipherTextTooShort, err)
+		_, err = OpenWithPassword(password, blob[:passwordHeaderSize+5])
+		require.Equal(t, ErrCipherTextTooShort, err)
+
+		unknown := append([]byte{}, blob...)
+		unknown[0] = 0x02
+		_, err = OpenWithPassword(password, unknown)
+		require.Equal(t, ErrUnsupportedVersion, err)
+
+		huge := append([]byte{}, blob...)
+		huge[5] = 0xff
+		_, err = OpenWithPassword(password, huge)
+		require.Equal(t, ErrInvalidKDFParams, err)
+	})
+
+	t.Run("oversized params", func(t *testing.T) {
+		blob, err := SealWithPassword(password, plainText, fast)
+		require.NoError(t, err)
+
+		// the kdf never finishes with these, so the rejection proves it does not run
+		for name, patch := range map[string]func(header []byte){
+			"time":    func(header []byte) { binary.BigEndian.PutUint32(header[1:], 0xffffffff) },
+			"memory":  func(header []byte) { binary.BigEndian.PutUint32(header[5:], passwordMaxMemory+1) },
+			"threads": func(header []byte) { header[9] = passwordMaxThreads + 1 },
+		} {
+			oversized := append([]byte{}, blob...)
+			patch(oversized)
+			_, err = OpenWithPassword(password, oversized)
+			require.Equal(t, ErrInvalidKDFParams, err, name)
+		}
+
+		_, err = SealWithPassword(password, plainText, WithArgon2id(passwordMaxTime+1, 1024, 1))
+		require.Equal(t, ErrInvalidKDFParams, err)
+		_, err = SealWithPassword(password, plainText, WithArgon2id(1, passwordMaxMemory+1, 1))
+		require.Equal(t, ErrInvalidKDFParams, err)
+		_, err = SealWithPassword(password, plainText, WithArgon2id(1, 1024, passwordMaxThreads+1))
+		require.Equal(t, ErrInvalidKDFParams, err)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := SealWithPassword(password, plainText, WithArgon2id(0, 1024, 1))
+		require.Equal(t, ErrInvalidKDFParams, err)
+		_, err = SealWithPassword(password, plainText, WithArgon2id(1, 1024, 0))
+		require.Equal(t, ErrInvalidKDFParams, err)
+		_, err = SealWithPassword(password, plainText, fast, WithRand(bytes.NewReader(nil)))
+		require.Error(t, err)
+	})
+}
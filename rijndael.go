@@ -0,0 +1,204 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// error defined
+var (
+	ErrInvalidRijndaelKeySize   = errors.New("rijndael key length must be 16, 24 or 32 bytes")
+	ErrInvalidRijndaelBlockSize = errors.New("rijndael block size must be 16, 24 or 32 bytes")
+)
+
+// NewRijndael new rijndael cipher.Block with key and block size, both of them must be 16, 24 or 32 bytes,
+// rijndael with 16 bytes block size is aes, use crypto/aes for it, which is faster and constant time.
+// it is for interoperating with the legacy system only, such as .NET RijndaelManaged with
+// the 192 or 256 bits BlockSize. see NewRijndael192 and NewRijndael256 for newCipher.
+// NOTE: the 192 and 256 bits block size are tested against the specification, not the .NET output.
+// NOTE: it is a table based implementation, which is not constant time.
+func NewRijndael(key []byte, blockSize int) (cipher.Block, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidRijndaelKeySize
+	}
+	switch blockSize {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidRijndaelBlockSize
+	}
+	nb, nk := blockSize/4, len(key)/4
+	nr := nb + 6
+	if nk > nb {
+		nr = nk + 6
+	}
+	return &rijndael{nb, nr, rijndaelExpandKey(key, nb, nr)}, nil
+}
+
+// NewRijndael192 new rijndael cipher.Block with 192 bits(24 bytes) block size, see NewRijndael.
+// it implement newCipher, such as:
+//	bc, err := NewBlockCrypt(key, iv, NewRijndael192) // iv is 24 bytes
+func NewRijndael192(key []byte) (cipher.Block, error) { return NewRijndael(key, 24) }
+
+// NewRijndael256 new rijndael cipher.Block with 256 bits(32 bytes) block size, see NewRijndael.
+// it implement newCipher, such as .NET RijndaelManaged{BlockSize = 256} in cbc mode with pkcs7 padding:
+//	bc, err := NewBlockCrypt(key, iv, NewRijndael256) // iv is 32 bytes
+func NewRijndael256(key []byte) (cipher.Block, error) { return NewRijndael(key, 32) }
+
+// rijndael implement cipher.Block, the state is column major as the input bytes.
+type rijndael struct {
+	nb, nr int    // block size in 32-bit words, number of rounds
+	rk     []byte // round keys, 4*nb*(nr+1) bytes
+}
+
+// rijndaelShifts the ShiftRows offsets of the row 1, 2, 3 by nb.
+var rijndaelShifts = map[int][3]int{4: {1, 2, 3}, 6: {1, 2, 3}, 8: {1, 3, 4}}
+
+var rijndaelSBox, rijndaelInvSBox = newRijndaelSBox()
+
+func (sf *rijndael) BlockSize() int { return 4 * sf.nb }
+
+func (sf *rijndael) Encrypt(dst, src []byte) {
+	size := 4 * sf.nb
+	if len(src) < size {
+		panic("aesext: input not full block")
+	}
+	if len(dst) < size {
+		panic("aesext: output not full block")
+	}
+	var buf [32]byte
+	state := buf[:size]
+	copy(state, src)
+	xorBytes(state, state, sf.rk[:size])
+	for round := 1; round <= sf.nr; round++ {
+		for i := range state {
+			state[i] = rijndaelSBox[state[i]]
+		}
+		sf.shiftRows(state, false)
+		if round != sf.nr {
+			for c := 0; c < size; c += 4 {
+				rijndaelMixColumn(state[c:c+4], 0x02, 0x03, 0x01, 0x01)
+			}
+		}
+		xorBytes(state, state, sf.rk[round*size:])
+	}
+	copy(dst, state)
+}
+
+func (sf *rijndael) Decrypt(dst, src []byte) {
+	size := 4 * sf.nb
+	if len(src) < size {
+		panic("aesext: input not full block")
+	}
+	if len(dst) < size {
+		panic("aesext: output not full block")
+	}
+	var buf [32]byte
+	state := buf[:size]
+	copy(state, src)
+	for round := sf.nr; round >= 1; round-- {
+		xorBytes(state, state, sf.rk[round*size:])
+		if round != sf.nr {
+			for c := 0; c < size; c += 4 {
+				rijndaelMixColumn(state[c:c+4], 0x0e, 0x0b, 0x0d, 0x09)
+			}
+		}
+		sf.shiftRows(state, true)
+		for i := range state {
+			state[i] = rijndaelInvSBox[state[i]]
+		}
+	}
+	xorBytes(state, state, sf.rk[:size])
+	copy(dst, state)
+}
+
+// shiftRows cyclically shift the row r left by the offset, or right if inverse.
+func (sf *rijndael) shiftRows(state []byte, inverse bool) {
+	var row [8]byte
+	for r, shift := range rijndaelShifts[sf.nb] {
+		r++
+		if inverse {
+			shift = sf.nb - shift
+		}
+		for c := 0; c < sf.nb; c++ {
+			row[c] = state[r+4*((c+shift)%sf.nb)]
+		}
+		for c := 0; c < sf.nb; c++ {
+			state[r+4*c] = row[c]
+		}
+	}
+}
+
+// rijndaelMixColumn multiply the column by the circulant matrix with the first row a, b, c, d.
+func rijndaelMixColumn(col []byte, a, b, c, d byte) {
+	s0, s1, s2, s3 := col[0], col[1], col[2], col[3]
+	col[0] = gfMul(s0, a) ^ gfMul(s1, b) ^ gfMul(s2, c) ^ gfMul(s3, d)
+	col[1] = gfMul(s0, d) ^ gfMul(s1, a) ^ gfMul(s2, b) ^ gfMul(s3, c)
+	col[2] = gfMul(s0, c) ^ gfMul(s1, d) ^ gfMul(s2, a) ^ gfMul(s3, b)
+	col[3] = gfMul(s0, b) ^ gfMul(s1, c) ^ gfMul(s2, d) ^ gfMul(s3, a)
+}
+
+// rijndaelExpandKey the key schedule, returns the round keys of 4*nb*(nr+1) bytes.
+func rijndaelExpandKey(key []byte, nb, nr int) []byte {
+	nk := len(key) / 4
+	w := make([]byte, 4*nb*(nr+1))
+	copy(w, key)
+	rcon := byte(0x01)
+	for i := nk; i < nb*(nr+1); i++ {
+		var temp [4]byte
+		copy(temp[:], w[4*(i-1):])
+		switch {
+		case i%nk == 0:
+			temp[0], temp[1], temp[2], temp[3] = rijndaelSBox[temp[1]]^rcon, rijndaelSBox[temp[2]], rijndaelSBox[temp[3]], rijndaelSBox[temp[0]]
+			rcon = gfMul(rcon, 0x02)
+		case nk > 6 && i%nk == 4:
+			for j := range temp {
+				temp[j] = rijndaelSBox[temp[j]]
+			}
+		}
+		xorBytes(w[4*i:4*i+4], w[4*(i-nk):], temp[:])
+	}
+	return w
+}
+
+// gfMul multiply in GF(2^8) with the rijndael polynomial x^8 + x^4 + x^3 + x + 1.
+func gfMul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// newRijndaelSBox the S-box is the multiplicative inverse in GF(2^8) followed by the affine transformation.
+func newRijndaelSBox() (sbox, invSBox [256]byte) {
+	for i := 0; i < 256; i++ {
+		var inv byte
+		for j := 1; i != 0 && j < 256; j++ {
+			if gfMul(byte(i), byte(j)) == 1 {
+				inv = byte(j)
+				break
+			}
+		}
+		s := inv
+		for k := 1; k <= 4; k++ {
+			s ^= inv<<uint(k) | inv>>uint(8-k)
+		}
+		s ^= 0x63
+		sbox[i], invSBox[s] = s, byte(i)
+	}
+	return sbox, invSBox
+}
@@ -0,0 +1,98 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRijndael(t *testing.T) {
+	// all the combinations of the block and key length, the input extends the one of FIPS-197 appendix B.
+	// the 16 bytes block ones match crypto/aes, the others are cross-checked with an independent implementation
+	// of the rijndael specification only, the interop with .NET RijndaelManaged is not verified by its output.
+	plainText, _ := hex.DecodeString("3243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c8")
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfe")
+	tests := []struct {
+		blockSize int
+		keySize   int
+		want      string
+	}{
+		{16, 16, "3925841d02dc09fbdc118597196a0b32"},
+		{16, 24, "f9fb29aefc384a250340d833b87ebc00"},
+		{16, 32, "1a6e6c2c662e7da6501ffb62bc9e93f3"},
+		{24, 16, "b24d275489e82bb8f7375e0d5fcdb1f481757c538b65148a"},
+		{24, 24, "725ae43b5f3161de806a7c93e0bca93c967ec1ae1b71e1cf"},
+		{24, 32, "0ebacf199e3315c2e34b24fcc7c46ef4388aa475d66c194c"},
+		{32, 16, "7d15479076b69a46ffb3b3beae97ad8313f622f67fedb487de9f06b9ed9c8f19"},
+		{32, 24, "5d7101727bb25781bf6715b0e6955282b9610e23a43c2eb062699f0ebf5887b2"},
+		{32, 32, "a49406115dfb30a40418aafa4869b7c6a886ff31602a7dd19c889dc64f7e4e7a"},
+	}
+	for _, tt := range tests {
+		block, err := NewRijndael(key[:tt.keySize], tt.blockSize)
+		require.NoError(t, err)
+		assert.Equal(t, tt.blockSize, block.BlockSize())
+
+		dst := make([]byte, tt.blockSize)
+		block.Encrypt(dst, plainText[:tt.blockSize])
+		assert.Equal(t, tt.want, hex.EncodeToString(dst), "block %d key %d", tt.blockSize, tt.keySize)
+		block.Decrypt(dst, dst)
+		assert.Equal(t, plainText[:tt.blockSize], dst)
+	}
+
+	t.Run("aes", func(t *testing.T) {
+		for _, keySize := range aesKeySizes {
+			block, err := NewRijndael(key[:keySize], 16)
+			require.NoError(t, err)
+			want, err := aes.NewCipher(key[:keySize])
+			require.NoError(t, err)
+
+			src, dst, wantDst := append([]byte{}, plainText[:16]...), make([]byte, 16), make([]byte, 16)
+			for i := 0; i < 100; i++ {
+				block.Encrypt(dst, src)
+				want.Encrypt(wantDst, src)
+				require.Equal(t, wantDst, dst)
+				copy(src, dst)
+			}
+		}
+	})
+
+	t.Run("cbc", func(t *testing.T) {
+		msg := []byte("helloworld,this is golang language. welcome")
+		for _, newCipher := range []func(key []byte) (cipher.Block, error){NewRijndael192, NewRijndael256} {
+			block, err := newCipher(key)
+			require.NoError(t, err)
+			bc, err := NewBlockCrypt(key, plainText[:block.BlockSize()], newCipher)
+			require.NoError(t, err)
+			assert.Equal(t, block.BlockSize(), bc.BlockSize())
+
+			cipherText, err := bc.Encrypt(msg)
+			require.NoError(t, err)
+			assert.Zero(t, len(cipherText)%bc.BlockSize())
+			got, err := bc.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, msg, got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, keySize := range []int{0, 8, 20, 33} {
+			_, err := NewRijndael(make([]byte, keySize), 32)
+			require.Equal(t, ErrInvalidRijndaelKeySize, err)
+		}
+		for _, blockSize := range []int{0, 8, 20, 64} {
+			_, err := NewRijndael(key[:16], blockSize)
+			require.Equal(t, ErrInvalidRijndaelBlockSize, err)
+		}
+		_, err := NewBlockCrypt(key, plainText[:16], NewRijndael256)
+		require.ErrorIs(t, err, ErrInvalidIvSize)
+
+		block, err := NewRijndael256(key)
+		require.NoError(t, err)
+		require.Panics(t, func() { block.Encrypt(make([]byte, 32), make([]byte, 16)) })
+		require.Panics(t, func() { block.Decrypt(make([]byte, 16), make([]byte, 32)) })
+	})
+}
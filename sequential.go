@@ -0,0 +1,137 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"bytes"
+	"crypto/cipher"
+)
+
+// SequentialEncrypter encrypt a sequence of messages as one logical chain, such as cbc,
+// the last cipher text block of a message is carried forward as the iv of the next message.
+// it holds a live cipher.BlockMode, so it is not safe for concurrent use.
+type SequentialEncrypter interface {
+	// Encrypt encrypt the next message of the chain, the first message has the iv prepended
+	// if the crypt is random iv mode or WithIVPrefix.
+	Encrypt(plainText []byte) ([]byte, error)
+	// EncryptTo same as Encrypt, appends the cipher text to dst, takes no allocations if dst has enough capacity.
+	EncryptTo(dst, plainText []byte) ([]byte, error)
+}
+
+// SequentialDecrypter decrypt the messages encrypted by SequentialEncrypter, in the same order.
+// once a message fails to decrypt, the chain is broken, all the following calls return the error.
+// it is not safe for concurrent use.
+type SequentialDecrypter interface {
+	// Decrypt decrypt the next message of the chain.
+	Decrypt(cipherText []byte) ([]byte, error)
+}
+
+// NewSequentialEncrypter returns the SequentialEncrypter of bc, a padding mode message
+// still padded individually, so each Encrypt output is block aligned.
+// bc must be created by this package, otherwise return ErrStreamNotSupported.
+func NewSequentialEncrypter(bc BlockCrypt) (SequentialEncrypter, error) {
+	bb, ok := toBlockBlock(bc)
+	if !ok {
+		return nil, ErrStreamNotSupported
+	}
+	return &sequentialEncrypter{bb: bb}, nil
+}
+
+// NewSequentialDecrypter returns the SequentialDecrypter of bc.
+// bc must be created by this package, otherwise return ErrStreamNotSupported.
+func NewSequentialDecrypter(bc BlockCrypt) (SequentialDecrypter, error) {
+	bb, ok := toBlockBlock(bc)
+	if !ok {
+		return nil, ErrStreamNotSupported
+	}
+	return &sequentialDecrypter{bb: bb}, nil
+}
+
+type sequentialEncrypter struct {
+	bb   *blockBlock
+	mode cipher.BlockMode
+}
+
+// Encrypt implement SequentialEncrypter
+func (sf *sequentialEncrypter) Encrypt(plainText []byte) ([]byte, error) {
+	return sf.EncryptTo(make([]byte, 0, sf.bb.encryptedSize(len(plainText))), plainText)
+}
+
+// EncryptTo implement SequentialEncrypter
+func (sf *sequentialEncrypter) EncryptTo(dst, plainText []byte) ([]byte, error) {
+	bb := sf.bb
+	blockSize := bb.block.BlockSize()
+	if !bb.stream && bb.noPadding && len(plainText)%blockSize != 0 {
+		return nil, ErrInputNotMultipleBlocks
+	}
+	if sf.mode == nil {
+		header, mode, err := bb.encrypter()
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, header...)
+		sf.mode = mode
+	}
+
+	start := len(dst)
+	if bb.stream || bb.noPadding {
+		dst = grow(dst, len(plainText))
+		sf.mode.CryptBlocks(dst[start:], plainText)
+		return dst, nil
+	}
+	padSize := blockSize - len(plainText)%blockSize
+	dst = append(grow(dst, len(plainText)+padSize)[:start], plainText...)
+	padded, err := pad(bb.padding, dst[start:], blockSize)
+	if err != nil {
+		return nil, err
+	}
+	dst = append(dst[:start], padded...)
+	sf.mode.CryptBlocks(dst[start:], dst[start:])
+	return dst, nil
+}
+
+type sequentialDecrypter struct {
+	bb   *blockBlock
+	mode cipher.BlockMode
+	err  error
+}
+
+// Decrypt implement SequentialDecrypter
+func (sf *sequentialDecrypter) Decrypt(cipherText []byte) ([]byte, error) {
+	if sf.err != nil {
+		return nil, sf.err
+	}
+	bb := sf.bb
+	if err := bb.checkCipherSize(cipherText); err != nil {
+		return nil, err
+	}
+	if sf.mode == nil {
+		mode, err := bb.decrypter(bytes.NewReader(cipherText))
+		if err != nil {
+			return nil, err
+		}
+		if bb.prefixIV() {
+			cipherText = cipherText[bb.block.BlockSize():]
+		}
+		sf.mode = mode
+	}
+
+	blockSize := bb.block.BlockSize()
+	if !bb.stream && ((len(cipherText) == 0 && !bb.allowEmpty()) || len(cipherText)%blockSize != 0) {
+		sf.err = ErrInputNotMultipleBlocks
+		return nil, sf.err
+	}
+	out := make([]byte, len(cipherText))
+	sf.mode.CryptBlocks(out, cipherText)
+	if bb.stream || bb.noPadding {
+		return out, nil
+	}
+	plainText, err := unPad(bb.padding, out, bb.block.BlockSize())
+	if err != nil {
+		sf.err = err
+		return nil, err
+	}
+	return plainText, nil
+}
@@ -0,0 +1,202 @@
+package aesext
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequential(t *testing.T) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	messages := [][]byte{
+		[]byte("helloworld,this is golang language. welcome"),
+		{},
+		[]byte("the second message"),
+		bytes.Repeat([]byte{0x01}, 2*aes.BlockSize),
+	}
+
+	t.Run("one logical chain", func(t *testing.T) {
+		bc, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		noPadding, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+
+		enc, err := NewSequentialEncrypter(bc)
+		require.NoError(t, err)
+		var chain, padded []byte
+		cipherTexts := make([][]byte, 0, len(messages))
+		for _, msg := range messages {
+			cipherText, err := enc.Encrypt(msg)
+			require.NoError(t, err)
+			chain = append(chain, cipherText...)
+			cipherTexts = append(cipherTexts, cipherText)
+			padded = append(padded, PCKSPadding(msg, aes.BlockSize)...)
+		}
+		want, err := noPadding.Encrypt(padded)
+		require.NoError(t, err)
+		assert.Equal(t, want, chain)
+
+		dec, err := NewSequentialDecrypter(bc)
+		require.NoError(t, err)
+		for i, cipherText := range cipherTexts {
+			got, err := dec.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(messages[i], got))
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"ctr", []Option{WithStreamCodec(cipher.NewCTR, cipher.NewCTR)}},
+		{"iv prefix", []Option{WithIVPrefix()}},
+		{"no padding", []Option{WithNoPadding()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bc, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher, tt.opts...)
+			require.NoError(t, err)
+			enc, err := NewSequentialEncrypter(bc)
+			require.NoError(t, err)
+			dec, err := NewSequentialDecrypter(bc)
+			require.NoError(t, err)
+			for _, msg := range messages[2:] {
+				if bc.(*blockBlock).noPadding {
+					msg = msg[:aes.BlockSize]
+				}
+				cipherText, err := enc.Encrypt(msg)
+				require.NoError(t, err)
+				got, err := dec.Decrypt(cipherText)
+				require.NoError(t, err)
+				assert.Equal(t, msg, got)
+			}
+		})
+	}
+
+	t.Run("random iv", func(t *testing.T) {
+		bc, err := NewBlockCryptRandomIV(key[:16], aes.NewCipher)
+		require.NoError(t, err)
+		enc, err := NewSequentialEncrypter(bc)
+		require.NoError(t, err)
+		dec, err := NewSequentialDecrypter(bc)
+		require.NoError(t, err)
+
+		first, err := enc.Encrypt(messages[0])
+		require.NoError(t, err)
+		assert.Equal(t, aes.BlockSize+48, len(first))
+		second, err := enc.Encrypt(messages[2])
+		require.NoError(t, err)
+		assert.Equal(t, 32, len(second))
+
+		for _, tc := range []struct {
+			cipherText, want []byte
+		}{{first, messages[0]}, {second, messages[2]}} {
+			got, err := dec.Decrypt(tc.cipherText)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		}
+	})
+
+	t.Run("zero padding empty message", func(t *testing.T) {
+		bc, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher, WithPadding(Zero{}))
+		require.NoError(t, err)
+		enc, err := NewSequentialEncrypter(bc)
+		require.NoError(t, err)
+		dec, err := NewSequentialDecrypter(bc)
+		require.NoError(t, err)
+
+		for _, msg := range [][]byte{nil, messages[0], {}, messages[2]} {
+			cipherText, err := enc.Encrypt(msg)
+			require.NoError(t, err)
+			got, err := dec.Decrypt(cipherText)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(msg, got))
+		}
+	})
+
+	t.Run("broken chain", func(t *testing.T) {
+		bc, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(t, err)
+		enc, err := NewSequentialEncrypter(bc)
+		require.NoError(t, err)
+		cipherText1, err := enc.Encrypt(messages[0])
+		require.NoError(t, err)
+		cipherText2, err := enc.Encrypt(messages[2])
+		require.NoError(t, err)
+
+		// out of order, cbc garbles the first block
+		dec, err := NewSequentialDecrypter(bc)
+		require.NoError(t, err)
+		got, err := dec.Decrypt(cipherText2)
+		if err == nil {
+			assert.NotEqual(t, messages[2], got)
+		}
+
+		dec, err = NewSequentialDecrypter(bc)
+		require.NoError(t, err)
+		_, err = dec.Decrypt(cipherText1[:1])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+		_, err = dec.Decrypt(cipherText1)
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		bc, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher, WithNoPadding())
+		require.NoError(t, err)
+		enc, err := NewSequentialEncrypter(bc)
+		require.NoError(t, err)
+		_, err = enc.Encrypt([]byte{0x01})
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+
+		randomIV, err := NewBlockCryptRandomIV(key[:16], aes.NewCipher, WithRand(bytes.NewReader(nil)))
+		require.NoError(t, err)
+		enc, err = NewSequentialEncrypter(randomIV)
+		require.NoError(t, err)
+		_, err = enc.Encrypt(messages[0])
+		require.Error(t, err)
+		dec, err := NewSequentialDecrypter(randomIV)
+		require.NoError(t, err)
+		_, err = dec.Decrypt([]byte{0x01})
+		require.Equal(t, ErrCipherTextTooShort, err)
+
+		_, err = NewSequentialEncrypter(mockBlockCrypt{bc})
+		require.Equal(t, ErrStreamNotSupported, err)
+		_, err = NewSequentialDecrypter(mockBlockCrypt{bc})
+		require.Equal(t, ErrStreamNotSupported, err)
+	})
+}
+
+func BenchmarkSequentialEncrypt(b *testing.B) {
+	key, iv := sha256.Sum256([]byte("secret_key")), sha256.Sum256([]byte("secret_iv"))
+	plainText := make([]byte, 256)
+
+	b.Run("new block crypt per message", func(b *testing.B) {
+		chainIV := append([]byte{}, iv[:aes.BlockSize]...)
+		b.ReportAllocs()
+		b.SetBytes(int64(len(plainText)))
+		for i := 0; i < b.N; i++ {
+			blk, _ := NewBlockCrypt(key[:16], chainIV, aes.NewCipher)
+			cipherText, _ := blk.Encrypt(plainText)
+			copy(chainIV, cipherText[len(cipherText)-aes.BlockSize:])
+		}
+	})
+
+	b.Run("sequential", func(b *testing.B) {
+		blk, err := NewBlockCrypt(key[:16], iv[:aes.BlockSize], aes.NewCipher)
+		require.NoError(b, err)
+		enc, err := NewSequentialEncrypter(blk)
+		require.NoError(b, err)
+		dst := make([]byte, 0, len(plainText)+aes.BlockSize)
+		b.ReportAllocs()
+		b.SetBytes(int64(len(plainText)))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			dst, _ = enc.EncryptTo(dst[:0], plainText)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrInvalidSIVKeySize siv key length must be 32, 48 or 64 bytes
+var ErrInvalidSIVKeySize = errors.New("siv key length must be 32, 48 or 64 bytes")
+
+// NewSIV new AES-SIV(RFC 5297) deterministic authenticated encryption with key and custom option,
+// the key is double length, the first half for S2V(cmac), the other for ctr,
+// 32, 48 or 64 bytes to select AES-SIV-CMAC-256, AES-SIV-CMAC-384 or AES-SIV-CMAC-512.
+// the same plain text and additional data always produce the same cipher text,
+// so the nonce size is 0, it is nonce-misuse-resistant and suitable for deduplicating.
+// option support:
+//      WithRand
+func NewSIV(key []byte, opts ...Option) (AEADCrypt, error) {
+	if len(key) != 32 && len(key) != 48 && len(key) != 64 {
+		return nil, ErrInvalidSIVKeySize
+	}
+	macBlock, err := newBlock(aes.NewCipher, key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	ctrBlock, err := newBlock(aes.NewCipher, key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	return &aeadCrypt{&siv{newCMAC(macBlock), ctrBlock}, newConfig(opts...).rand}, nil
+}
+
+// siv implement cipher.AEAD, the additional data is the only associated data component.
+type siv struct {
+	mac   *cmac
+	block cipher.Block
+}
+
+func (sf *siv) NonceSize() int { return 0 }
+
+func (sf *siv) Overhead() int { return aes.BlockSize }
+
+// tagPrefix the synthetic iv V is the tag, which is before the cipher text.
+func (sf *siv) tagPrefix() bool { return true }
+
+func (sf *siv) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != 0 {
+		panic("aesext: incorrect nonce length given to siv")
+	}
+	v := sf.s2v(additionalData, plaintext)
+	ret, out := sliceForAppend(dst, len(v)+len(plaintext))
+	copy(out, v)
+	cipher.NewCTR(sf.block, sivCounter(v)).XORKeyStream(out[len(v):], plaintext)
+	return ret
+}
+
+func (sf *siv) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != 0 {
+		panic("aesext: incorrect nonce length given to siv")
+	}
+	if len(ciphertext) < aes.BlockSize {
+		return nil, ErrAuthFailed
+	}
+	v, ciphertext := ciphertext[:aes.BlockSize], ciphertext[aes.BlockSize:]
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	cipher.NewCTR(sf.block, sivCounter(v)).XORKeyStream(out, ciphertext)
+	if !constantTimeEqual(v, sf.s2v(additionalData, out)) {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, ErrAuthFailed
+	}
+	return ret, nil
+}
+
+// s2v implement S2V with a single associated data component.
+func (sf *siv) s2v(additionalData, plaintext []byte) []byte {
+	d := sf.mac.sum(make([]byte, aes.BlockSize))
+	xorBytes(d, dbl(d), sf.mac.sum(additionalData))
+
+	var t []byte
+	if len(plaintext) >= aes.BlockSize {
+		// xorend
+		t = append([]byte{}, plaintext...)
+		xorBytes(t[len(t)-aes.BlockSize:], t[len(t)-aes.BlockSize:], d)
+	} else {
+		// pad
+		t = make([]byte, aes.BlockSize)
+		copy(t, plaintext)
+		t[len(plaintext)] = 0x80
+		xorBytes(t, t, dbl(d))
+	}
+	return sf.mac.sum(t)
+}
+
+// sivCounter clear the 31st and 63rd bit(from right) of v as the counter.
+func sivCounter(v []byte) []byte {
+	q := append([]byte{}, v...)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	return q
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	head = grow(in, n)
+	tail = head[len(in):]
+	return
+}
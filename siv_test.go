@@ -0,0 +1,78 @@
+package aesext
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSIV(t *testing.T) {
+	t.Run("rfc 5297 deterministic", func(t *testing.T) {
+		// RFC 5297 appendix A.1
+		key, _ := hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+		ad, _ := hex.DecodeString("101112131415161718191a1b1c1d1e1f2021222324252627")
+		plainText, _ := hex.DecodeString("112233445566778899aabbccddee")
+		want := "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c"
+
+		bc, err := NewSIV(key)
+		require.NoError(t, err)
+		assert.Equal(t, 0, bc.NonceSize())
+		assert.Equal(t, 16, bc.Overhead())
+
+		cipherText := bc.Seal(nil, nil, plainText, ad)
+		assert.Equal(t, want, hex.EncodeToString(cipherText))
+
+		got, err := bc.Open(nil, nil, cipherText, ad)
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		key := make([]byte, 64)
+		for i := range key {
+			key[i] = byte(i)
+		}
+		for _, keySize := range []int{32, 48, 64} {
+			bc, err := NewSIV(key[:keySize])
+			require.NoError(t, err)
+			for _, plainText := range [][]byte{
+				{},
+				[]byte("short"),
+				[]byte("helloworld,this is golang language. welcome"),
+			} {
+				cipherText1 := bc.Seal(nil, nil, plainText, []byte("record id"))
+				cipherText2 := bc.Seal(nil, nil, plainText, []byte("record id"))
+				assert.Equal(t, cipherText1, cipherText2)
+
+				cipherText3 := bc.Seal(nil, nil, plainText, []byte("record id 2"))
+				assert.NotEqual(t, cipherText1, cipherText3)
+
+				got, err := bc.Open(nil, nil, cipherText1, []byte("record id"))
+				require.NoError(t, err)
+				assert.True(t, bytes.Equal(plainText, got))
+
+				_, err = bc.Open(nil, nil, cipherText1, []byte("record id 2"))
+				require.Equal(t, ErrAuthFailed, err)
+				cipherText1[len(cipherText1)-1] ^= 0x01
+				_, err = bc.Open(nil, nil, cipherText1, []byte("record id"))
+				require.Equal(t, ErrAuthFailed, err)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewSIV(make([]byte, 16))
+		require.Equal(t, ErrInvalidSIVKeySize, err)
+
+		bc, err := NewSIV(make([]byte, 32))
+		require.NoError(t, err)
+		require.Panics(t, func() { bc.Seal(nil, []byte{0x01}, nil, nil) })
+		_, err = bc.Open(nil, []byte{0x01}, make([]byte, 16), nil)
+		require.Equal(t, ErrInvalidNonceSize, err)
+		_, err = bc.Open(nil, nil, make([]byte, 15), nil)
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+}
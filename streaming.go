@@ -0,0 +1,261 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"context"
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+// error defined
+var (
+	ErrStreamNotSupported = errors.New("block crypt not support streaming")
+	ErrWriterClosed       = errors.New("write to closed writer")
+)
+
+// WriterResetter the writer returned by NewEncryptWriter implement it, callers type-assert it.
+type WriterResetter interface {
+	// Reset discard any buffered data and the chaining state, start a new message write to w,
+	// a random iv crypt generate a fresh iv for the new message.
+	Reset(w io.Writer)
+}
+
+// NewEncryptWriter returns a writer, data written to it is encrypted and written to w.
+// it buffers input to block boundaries and encrypts full blocks as they arrive,
+// the padding is applied on Close, so Close must be called to flush the final block.
+// Close does not close the underlying writer.
+// the output is the same as a one-shot bc.Encrypt, the returned writer implement WriterResetter.
+// bc must be created by this package without WithMinPadTo, otherwise all the writes return ErrStreamNotSupported.
+func NewEncryptWriter(w io.Writer, bc BlockCrypt) io.WriteCloser {
+	return NewEncryptWriterContext(context.Background(), w, bc)
+}
+
+// NewEncryptWriterContext same as NewEncryptWriter, but with a context.
+// the ctx is checked before each Write and Close, once it is done, they return ctx.Err().
+func NewEncryptWriterContext(ctx context.Context, w io.Writer, bc BlockCrypt) io.WriteCloser {
+	ew := &encryptWriter{ctx: ctx, w: w}
+	if bb, ok := toBlockBlock(bc); ok && bb.streamable() {
+		ew.bb = bb
+	} else {
+		ew.err = ErrStreamNotSupported
+	}
+	return ew
+}
+
+type encryptWriter struct {
+	ctx  context.Context
+	w    io.Writer
+	bb   *blockBlock
+	mode cipher.BlockMode
+	buf  []byte // pending data which not fill a full block
+	err  error
+}
+
+func (sf *encryptWriter) init() error {
+	if sf.mode != nil {
+		return nil
+	}
+	header, mode, err := sf.bb.encrypter()
+	if err != nil {
+		return err
+	}
+	if len(header) > 0 {
+		if _, err = sf.w.Write(header); err != nil {
+			return err
+		}
+	}
+	sf.mode = mode
+	return nil
+}
+
+// Write implement io.Writer
+func (sf *encryptWriter) Write(p []byte) (int, error) {
+	if sf.err != nil {
+		return 0, sf.err
+	}
+	if sf.err = sf.ctx.Err(); sf.err != nil {
+		return 0, sf.err
+	}
+	if sf.err = sf.init(); sf.err != nil {
+		return 0, sf.err
+	}
+
+	sf.buf = append(sf.buf, p...)
+	n := len(sf.buf) - len(sf.buf)%sf.mode.BlockSize()
+	if n > 0 {
+		sf.mode.CryptBlocks(sf.buf[:n], sf.buf[:n])
+		if _, sf.err = sf.w.Write(sf.buf[:n]); sf.err != nil {
+			return 0, sf.err
+		}
+		sf.buf = append(sf.buf[:0], sf.buf[n:]...)
+	}
+	return len(p), nil
+}
+
+// Reset implement WriterResetter, the writer can be reused after Close or in mid-stream.
+func (sf *encryptWriter) Reset(w io.Writer) {
+	sf.w = w
+	sf.mode = nil
+	sf.buf = sf.buf[:0]
+	sf.err = nil
+	if sf.bb == nil {
+		sf.err = ErrStreamNotSupported
+	}
+}
+
+// Close flush the final block with padding, it does not close the underlying writer.
+func (sf *encryptWriter) Close() error {
+	if sf.err != nil {
+		if sf.err == ErrWriterClosed {
+			return nil
+		}
+		return sf.err
+	}
+	if sf.err = sf.ctx.Err(); sf.err != nil {
+		return sf.err
+	}
+	if sf.err = sf.init(); sf.err != nil {
+		return sf.err
+	}
+	last := sf.buf
+	switch {
+	case sf.bb.stream:
+	case sf.bb.noPadding:
+		if len(last) != 0 {
+			sf.err = ErrInputNotMultipleBlocks
+			return sf.err
+		}
+	default:
+		if last, sf.err = pad(sf.bb.padding, last, sf.mode.BlockSize()); sf.err != nil {
+			return sf.err
+		}
+	}
+	sf.mode.CryptBlocks(last, last)
+	if _, sf.err = sf.w.Write(last); sf.err != nil {
+		return sf.err
+	}
+	sf.buf = nil
+	sf.err = ErrWriterClosed
+	return nil
+}
+
+// NewDecryptReader returns a reader, it reads cipher text from r and decrypt block by block.
+// the final block is held back until EOF of r, so the padding is only removed at the true end.
+// it returns ErrInputNotMultipleBlocks if the total cipher text is not block aligned.
+// bc must be created by this package without WithMinPadTo, otherwise all the reads return ErrStreamNotSupported.
+func NewDecryptReader(r io.Reader, bc BlockCrypt) io.Reader {
+	return NewDecryptReaderContext(context.Background(), r, bc)
+}
+
+// NewDecryptReaderContext same as NewDecryptReader, but with a context.
+// the ctx is checked before reading each chunk from r, once it is done, Read returns ctx.Err().
+func NewDecryptReaderContext(ctx context.Context, r io.Reader, bc BlockCrypt) io.Reader {
+	dr := &decryptReader{ctx: ctx, r: r}
+	if bb, ok := toBlockBlock(bc); ok && bb.streamable() {
+		dr.bb = bb
+		dr.chunk = make([]byte, 4096)
+	} else {
+		dr.err = ErrStreamNotSupported
+	}
+	return dr
+}
+
+type decryptReader struct {
+	ctx   context.Context
+	r     io.Reader
+	bb    *blockBlock
+	mode  cipher.BlockMode
+	chunk []byte
+	in    []byte // cipher text which not be decrypted
+	out   []byte // plain text which not be read
+	err   error
+}
+
+// Read implement io.Reader
+func (sf *decryptReader) Read(p []byte) (int, error) {
+	for len(sf.out) == 0 {
+		if sf.err != nil {
+			return 0, sf.err
+		}
+		sf.fill()
+	}
+	n := copy(p, sf.out)
+	sf.out = sf.out[n:]
+	return n, nil
+}
+
+func (sf *decryptReader) fill() {
+	if sf.err = sf.ctx.Err(); sf.err != nil {
+		return
+	}
+	if sf.mode == nil {
+		if sf.mode, sf.err = sf.bb.decrypter(sf.r); sf.err != nil {
+			return
+		}
+	}
+
+	n, err := sf.r.Read(sf.chunk)
+	sf.in = append(sf.in, sf.chunk[:n]...)
+	if err != nil && err != io.EOF {
+		sf.err = err
+		return
+	}
+
+	blockSize := sf.mode.BlockSize()
+	if err == io.EOF {
+		sf.err = io.EOF
+		if !sf.bb.stream && ((len(sf.in) == 0 && !sf.bb.allowEmpty()) || len(sf.in)%blockSize != 0) {
+			sf.err = ErrInputNotMultipleBlocks
+			return
+		}
+		sf.mode.CryptBlocks(sf.in, sf.in)
+		if sf.bb.stream || sf.bb.noPadding {
+			sf.out, sf.in = sf.in, nil
+			return
+		}
+		sf.out, err = unPad(sf.bb.padding, sf.in, sf.bb.block.BlockSize())
+		if err != nil {
+			sf.err = err
+		}
+		sf.in = nil
+		return
+	}
+
+	size := len(sf.in)
+	if !sf.bb.stream {
+		size -= len(sf.in) % blockSize
+		// held back the final block until we know whether more data follows.
+		if size == len(sf.in) {
+			size -= blockSize
+		}
+	}
+	if size > 0 {
+		sf.mode.CryptBlocks(sf.in[:size], sf.in[:size])
+		sf.out = append(sf.out[:0], sf.in[:size]...)
+		sf.in = append(sf.in[:0], sf.in[size:]...)
+	}
+}
+
+// toBlockBlock returns the underlying *blockBlock of the BlockCrypt created by this package.
+func toBlockBlock(bc BlockCrypt) (*blockBlock, bool) {
+	switch v := bc.(type) {
+	case *blockBlock:
+		return v, true
+	case *ctrCrypt:
+		return v.blockBlock, true
+	case *ofbCrypt:
+		return v.blockBlock, true
+	default:
+		return nil, false
+	}
+}
+
+// streamable reports whether the padding fits in the final block, which streaming requires.
+func (sf *blockBlock) streamable() bool {
+	_, long := sf.padding.(minPadTo)
+	return !long || sf.stream || sf.noPadding
+}
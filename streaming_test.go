@@ -0,0 +1,262 @@
+package aesext
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBlockCrypt struct{ BlockCrypt }
+
+func TestEncryptWriter(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+	iv := key[:aes.BlockSize]
+
+	plainText := make([]byte, 10<<20)
+	for i := range plainText {
+		plainText[i] = byte(i * 7)
+	}
+
+	cbc, err := NewBlockCrypt(key[:], iv, aes.NewCipher)
+	require.NoError(t, err)
+	ctr, err := NewBlockCrypt(key[:], iv, aes.NewCipher, WithStreamCodec(cipher.NewCTR, cipher.NewCTR))
+	require.NoError(t, err)
+	randomIV, err := NewBlockCryptRandomIV(key[:], aes.NewCipher, WithRand(bytes.NewReader(iv)))
+	require.NoError(t, err)
+	noPadding, err := NewBlockCrypt(key[:], iv, aes.NewCipher, WithNoPadding())
+	require.NoError(t, err)
+	ivPrefix, err := NewBlockCrypt(key[:], iv, aes.NewCipher, WithIVPrefix())
+	require.NoError(t, err)
+	randomIVWant, err := NewBlockCryptRandomIV(key[:], aes.NewCipher, WithRand(bytes.NewReader(iv)))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		bc   BlockCrypt
+		want BlockCrypt
+		data []byte
+	}{
+		{"cbc 10MB", cbc, cbc, plainText},
+		{"cbc aligned", cbc, cbc, plainText[:4*aes.BlockSize]},
+		{"cbc empty", cbc, cbc, []byte{}},
+		{"cbc no padding", noPadding, noPadding, plainText[:4*aes.BlockSize]},
+		{"ctr 10MB", ctr, ctr, plainText[:len(plainText)-3]},
+		{"random iv", randomIV, randomIVWant, plainText[:1000]},
+		{"iv prefix", ivPrefix, ivPrefix, plainText[:1000]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.want.Encrypt(tt.data)
+			require.NoError(t, err)
+
+			out := &bytes.Buffer{}
+			w := NewEncryptWriter(out, tt.bc)
+			for data := tt.data; len(data) > 0; {
+				n := 4096
+				if n > len(data) {
+					n = len(data)
+				}
+				written, err := w.Write(data[:n])
+				require.NoError(t, err)
+				require.Equal(t, n, written)
+				data = data[n:]
+			}
+			require.NoError(t, w.Close())
+			assert.Equal(t, want, out.Bytes())
+
+			require.NoError(t, w.Close())
+			_, err = w.Write([]byte{0x01})
+			require.Equal(t, ErrWriterClosed, err)
+		})
+	}
+
+	t.Run("no padding not block aligned", func(t *testing.T) {
+		w := NewEncryptWriter(&bytes.Buffer{}, noPadding)
+		_, err := w.Write(plainText[:aes.BlockSize+1])
+		require.NoError(t, err)
+		require.Equal(t, ErrInputNotMultipleBlocks, w.Close())
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		w := NewEncryptWriter(&bytes.Buffer{}, mockBlockCrypt{cbc})
+		_, err := w.Write([]byte{0x01})
+		require.Equal(t, ErrStreamNotSupported, err)
+		require.Equal(t, ErrStreamNotSupported, w.Close())
+	})
+}
+
+func TestDecryptReader(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+	iv := key[:aes.BlockSize]
+
+	plainText := make([]byte, 1<<20)
+	for i := range plainText {
+		plainText[i] = byte(i * 7)
+	}
+
+	cbc, err := NewBlockCrypt(key[:], iv, aes.NewCipher)
+	require.NoError(t, err)
+	ctr, err := NewBlockCrypt(key[:], iv, aes.NewCipher, WithStreamCodec(cipher.NewCTR, cipher.NewCTR))
+	require.NoError(t, err)
+	randomIV, err := NewBlockCryptRandomIV(key[:], aes.NewCipher)
+	require.NoError(t, err)
+	noPadding, err := NewBlockCrypt(key[:], iv, aes.NewCipher, WithNoPadding())
+	require.NoError(t, err)
+	ivPrefix, err := NewBlockCrypt(key[:], iv, aes.NewCipher, WithIVPrefix())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		bc   BlockCrypt
+		data []byte
+	}{
+		{"cbc 1MB", cbc, plainText},
+		{"cbc aligned", cbc, plainText[:4*aes.BlockSize]},
+		{"cbc empty", cbc, []byte{}},
+		{"ctr", ctr, plainText[:len(plainText)-3]},
+		{"cbc no padding", noPadding, plainText[:4*aes.BlockSize]},
+		{"random iv", randomIV, plainText[:1000]},
+		{"iv prefix", ivPrefix, plainText[:1000]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cipherText, err := tt.bc.Encrypt(tt.data)
+			require.NoError(t, err)
+
+			readers := map[string]io.Reader{
+				"normal":    bytes.NewReader(cipherText),
+				"one byte":  iotest.OneByteReader(bytes.NewReader(cipherText)),
+				"half":      iotest.HalfReader(bytes.NewReader(cipherText)),
+				"data eof":  iotest.DataErrReader(bytes.NewReader(cipherText)),
+				"multi hop": io.MultiReader(bytes.NewReader(cipherText[:7]), bytes.NewReader(cipherText[7:])),
+			}
+			for name, r := range readers {
+				got, err := ioutil.ReadAll(NewDecryptReader(r, tt.bc))
+				require.NoError(t, err, name)
+				require.Equal(t, len(tt.data), len(got), name)
+				assert.Equal(t, tt.data, got, name)
+			}
+		})
+	}
+
+	t.Run("not block aligned", func(t *testing.T) {
+		cipherText, err := cbc.Encrypt(plainText[:5000])
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(NewDecryptReader(bytes.NewReader(cipherText[:len(cipherText)-1]), cbc))
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+		_, err = ioutil.ReadAll(NewDecryptReader(bytes.NewReader(nil), cbc))
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+	t.Run("random iv too short", func(t *testing.T) {
+		_, err = ioutil.ReadAll(NewDecryptReader(bytes.NewReader(iv[:3]), randomIV))
+		require.Equal(t, ErrCipherTextTooShort, err)
+	})
+	t.Run("not supported", func(t *testing.T) {
+		_, err := NewDecryptReader(&bytes.Buffer{}, mockBlockCrypt{cbc}).Read(make([]byte, 16))
+		require.Equal(t, ErrStreamNotSupported, err)
+	})
+}
+
+func TestStreamingContext(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+	iv := key[:aes.BlockSize]
+	plainText := make([]byte, 1<<20)
+
+	bc, err := NewBlockCrypt(key[:], iv, aes.NewCipher)
+	require.NoError(t, err)
+	cipherText, err := bc.Encrypt(plainText)
+	require.NoError(t, err)
+
+	t.Run("encrypt writer", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		out := &bytes.Buffer{}
+		w := NewEncryptWriterContext(ctx, out, bc)
+		_, err := w.Write(plainText[:4096])
+		require.NoError(t, err)
+		written := out.Len()
+
+		cancel()
+		_, err = w.Write(plainText[4096:])
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, context.Canceled, w.Close())
+		assert.Equal(t, written, out.Len())
+	})
+
+	t.Run("decrypt reader", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := NewDecryptReaderContext(ctx, bytes.NewReader(cipherText), bc)
+		_, err := io.ReadFull(r, make([]byte, 4096))
+		require.NoError(t, err)
+
+		cancel()
+		_, err = ioutil.ReadAll(r)
+		require.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("not canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		got, err := ioutil.ReadAll(NewDecryptReaderContext(ctx, bytes.NewReader(cipherText), bc))
+		require.NoError(t, err)
+		assert.Equal(t, plainText, got)
+	})
+}
+
+func TestEncryptWriterReset(t *testing.T) {
+	key := sha256.Sum256([]byte("secret_key"))
+	files := [][]byte{
+		[]byte("helloworld,this is golang language. welcome"),
+		[]byte("the second file"),
+	}
+
+	bc, err := NewBlockCryptRandomIV(key[:], aes.NewCipher)
+	require.NoError(t, err)
+
+	w := NewEncryptWriter(nil, bc)
+	outs := make([]*bytes.Buffer, 0, len(files))
+	for _, file := range files {
+		out := &bytes.Buffer{}
+		w.(WriterResetter).Reset(out)
+		_, err = w.Write(file)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		outs = append(outs, out)
+	}
+	assert.NotEqual(t, outs[0].Bytes()[:aes.BlockSize], outs[1].Bytes()[:aes.BlockSize])
+	for i, out := range outs {
+		got, err := bc.Decrypt(out.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, files[i], got)
+	}
+
+	t.Run("mid-stream", func(t *testing.T) {
+		w.(WriterResetter).Reset(&bytes.Buffer{})
+		_, err = w.Write(files[0][:20])
+		require.NoError(t, err)
+
+		out := &bytes.Buffer{}
+		w.(WriterResetter).Reset(out)
+		_, err = w.Write(files[1])
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		got, err := bc.Decrypt(out.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, files[1], got)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		w := NewEncryptWriter(&bytes.Buffer{}, mockBlockCrypt{bc})
+		w.(WriterResetter).Reset(&bytes.Buffer{})
+		_, err := w.Write([]byte{0x01})
+		require.Equal(t, ErrStreamNotSupported, err)
+	})
+}
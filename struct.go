@@ -0,0 +1,136 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"errors"
+	"reflect"
+)
+
+// error defined
+var (
+	ErrInvalidStructValue   = errors.New("value must be a non-nil pointer to struct")
+	ErrUnsupportedFieldType = errors.New("encrypt field must be string or *string")
+)
+
+// struct tag
+const (
+	defaultStructTag   = "aesext"
+	structTagEncrypted = "encrypt"
+)
+
+// EncryptStruct encrypt the string fields marked with the struct tag in place,
+// the field value is replaced by the cipher text encoded with base64.StdEncoding.
+// tag is the struct tag key, default "aesext" if empty, the field is marked with `aesext:"encrypt"`.
+// v must be a non-nil pointer to struct, otherwise return ErrInvalidStructValue.
+// the marked field must be string or *string, otherwise return ErrUnsupportedFieldType,
+// the empty string and nil pointer are kept unchanged.
+// the nested struct and pointer to struct fields are walked recursively, unexported fields are ignored.
+// NOTE: it stops at the first error, the fields which have been processed are kept modified.
+//	type User struct {
+//		Name  string
+//		Phone string  `aesext:"encrypt"`
+//		Email *string `aesext:"encrypt"`
+//	}
+func EncryptStruct(bc BlockCrypt, v interface{}, tag string) error {
+	return walkStruct(v, tag, func(s string) (string, error) {
+		return EncryptBase64(bc, []byte(s))
+	})
+}
+
+// DecryptStruct decrypt the string fields marked with the struct tag in place,
+// which encrypted by EncryptStruct, see EncryptStruct.
+func DecryptStruct(bc BlockCrypt, v interface{}, tag string) error {
+	return walkStruct(v, tag, func(s string) (string, error) {
+		plainText, err := DecryptBase64(bc, s)
+		if err != nil {
+			return "", err
+		}
+		return string(plainText), nil
+	})
+}
+
+func walkStruct(v interface{}, tag string, fn func(s string) (string, error)) error {
+	if tag == "" {
+		tag = defaultStructTag
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidStructValue
+	}
+	w := &structWalker{tag: tag, fn: fn, visited: make(map[visitedStruct]struct{})}
+	return w.walk(rv)
+}
+
+type structWalker struct {
+	tag string
+	fn  func(s string) (string, error)
+	// visited structs, avoid walking the cyclic struct forever
+	visited map[visitedStruct]struct{}
+}
+
+// visitedStruct the type is needed, the first field struct has the same address as its parent.
+type visitedStruct struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// walk rv which is a non-nil pointer to struct
+func (sf *structWalker) walk(rv reflect.Value) error {
+	key := visitedStruct{rv.Pointer(), rv.Type()}
+	if _, ok := sf.visited[key]; ok {
+		return nil
+	}
+	sf.visited[key] = struct{}{}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		fv := rv.Field(i)
+		if field.Tag.Get(sf.tag) == structTagEncrypted {
+			if err := sf.crypt(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			if err := sf.walk(fv.Addr()); err != nil {
+				return err
+			}
+		case fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct:
+			if err := sf.walk(fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sf *structWalker) crypt(fv reflect.Value) error {
+	switch {
+	case fv.Kind() == reflect.String:
+	case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.String:
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	default:
+		return ErrUnsupportedFieldType
+	}
+	if fv.Len() == 0 {
+		return nil
+	}
+	s, err := sf.fn(fv.String())
+	if err != nil {
+		return err
+	}
+	fv.SetString(s)
+	return nil
+}
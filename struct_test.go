@@ -0,0 +1,90 @@
+package aesext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City   string
+	Street string `aesext:"encrypt"`
+}
+
+type testUser struct {
+	Address testAddress
+	Name    string
+	Phone   string  `aesext:"encrypt"`
+	Email   *string `aesext:"encrypt"`
+	Remark  *string `aesext:"encrypt"`
+	Empty   string  `aesext:"encrypt"`
+	Note    string  `db:"encrypt"`
+	Backup  *testAddress
+	Next    *testUser
+	secret  string `aesext:"encrypt"`
+}
+
+func TestStruct(t *testing.T) {
+	bc, err := New([]byte("test"), []byte("a"))
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		email := "thinkgo@aliyun.com"
+		user := &testUser{
+			Address: testAddress{City: "city", Street: "street"},
+			Name:    "name",
+			Phone:   "13800000000",
+			Email:   &email,
+			Note:    "note",
+			Backup:  &testAddress{City: "backup city", Street: "backup street"},
+			secret:  "secret",
+		}
+		user.Next = user // cyclic
+
+		require.NoError(t, EncryptStruct(bc, user, ""))
+		want, err := EncryptBase64(bc, []byte("13800000000"))
+		require.NoError(t, err)
+		assert.Equal(t, want, user.Phone)
+		assert.NotEqual(t, "thinkgo@aliyun.com", *user.Email)
+		assert.NotEqual(t, "street", user.Address.Street)
+		assert.NotEqual(t, "backup street", user.Backup.Street)
+		assert.Equal(t, "name", user.Name)
+		assert.Equal(t, "city", user.Address.City)
+		assert.Equal(t, "note", user.Note)
+		assert.Equal(t, "secret", user.secret)
+		assert.Nil(t, user.Remark)
+		assert.Empty(t, user.Empty)
+
+		require.NoError(t, DecryptStruct(bc, user, ""))
+		assert.Equal(t, "13800000000", user.Phone)
+		assert.Equal(t, "thinkgo@aliyun.com", email)
+		assert.Equal(t, "street", user.Address.Street)
+		assert.Equal(t, "backup street", user.Backup.Street)
+	})
+
+	t.Run("custom tag", func(t *testing.T) {
+		user := &testUser{Phone: "13800000000", Note: "note"}
+		require.NoError(t, EncryptStruct(bc, user, "db"))
+		assert.Equal(t, "13800000000", user.Phone)
+		assert.NotEqual(t, "note", user.Note)
+		require.NoError(t, DecryptStruct(bc, user, "db"))
+		assert.Equal(t, "note", user.Note)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var nilUser *testUser
+		for _, v := range []interface{}{nil, testUser{}, nilUser, new(string)} {
+			require.Equal(t, ErrInvalidStructValue, EncryptStruct(bc, v, ""))
+			require.Equal(t, ErrInvalidStructValue, DecryptStruct(bc, v, ""))
+		}
+
+		type badField struct {
+			Age int `aesext:"encrypt"`
+		}
+		require.Equal(t, ErrUnsupportedFieldType, EncryptStruct(bc, &badField{}, ""))
+
+		user := &testUser{Phone: "not base64!"}
+		require.Error(t, DecryptStruct(bc, user, ""))
+	})
+}
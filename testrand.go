@@ -0,0 +1,35 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+// ZeroRand deterministic random source which always fill zero, pass it to WithRand
+// for reproducible iv, nonce and salt in test.
+// WARNING: it is for test only, never use it in production, it breaks all the security.
+type ZeroRand struct{}
+
+// Read implement io.Reader, never fail.
+func (ZeroRand) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// SequentialRand deterministic random source which fill the sequential bytes 0x00, 0x01, ..., 0xff, 0x00, ...
+// continued across the reads, the zero value is ready to use, pass &SequentialRand{} to WithRand
+// for reproducible and distinct iv, nonce and salt in test. it is not safe for concurrent use.
+// WARNING: it is for test only, never use it in production, it breaks all the security.
+type SequentialRand struct {
+	next byte
+}
+
+// Read implement io.Reader, never fail.
+func (sf *SequentialRand) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = sf.next
+		sf.next++
+	}
+	return len(p), nil
+}
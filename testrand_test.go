@@ -0,0 +1,61 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZeroRand(t *testing.T) {
+	p := []byte{1, 2, 3}
+	n, err := ZeroRand{}.Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []byte{0, 0, 0}, p)
+
+	key := []byte("0123456789abcdef")
+	bc, err := NewBlockCryptRandomIV(key, aes.NewCipher, WithRand(ZeroRand{}))
+	require.NoError(t, err)
+	want, err := NewBlockCrypt(key, make([]byte, aes.BlockSize), aes.NewCipher)
+	require.NoError(t, err)
+
+	cipherText, err := bc.Encrypt([]byte("helloworld"))
+	require.NoError(t, err)
+	wantCipherText, err := want.Encrypt([]byte("helloworld"))
+	require.NoError(t, err)
+	assert.Equal(t, append(make([]byte, aes.BlockSize), wantCipherText...), cipherText)
+}
+
+func TestSequentialRand(t *testing.T) {
+	r := &SequentialRand{}
+	p := make([]byte, 3)
+	n, err := r.Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []byte{0, 1, 2}, p)
+	_, err = r.Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{3, 4, 5}, p)
+
+	p = make([]byte, 256)
+	_, err = r.Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, byte(6), p[0])
+	assert.Equal(t, byte(0xff), p[249])
+	assert.Equal(t, byte(0), p[250])
+
+	key := []byte("0123456789abcdef")
+	seal := func() []byte {
+		ad, err := NewAEAD(key, aes.NewCipher, WithRand(&SequentialRand{}))
+		require.NoError(t, err)
+		first, err := ad.SealRandom([]byte("helloworld"), nil)
+		require.NoError(t, err)
+		second, err := ad.SealRandom([]byte("helloworld"), nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+		return first
+	}
+	assert.Equal(t, seal(), seal())
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package aesext
+
+import (
+	"crypto/cipher"
+	"fmt"
+
+	"golang.org/x/crypto/xts"
+)
+
+// XTSCrypt xts crypt interface, for block-addressable storage encryption, such as disk sectors.
+// the sector number is used as the tweak, so the same data at different sectors
+// encrypt to different cipher text. the data length must be multiple of 16 bytes,
+// cipher text stealing is not supported, empty data round-trips to empty.
+type XTSCrypt interface {
+	// Encrypt plain text of the sector. return cipher text, the length equal plain text length.
+	// the plain text is never modified.
+	Encrypt(sectorNum uint64, plainText []byte) ([]byte, error)
+	// Decrypt cipher text of the sector. return plain text.
+	// the cipher text is never modified.
+	Decrypt(sectorNum uint64, cipherText []byte) ([]byte, error)
+}
+
+// NewXTS new xts mode with newCipher, key,
+// the key must be twice the cipher key size, the first half for data, the other for tweak,
+// such as 32 bytes for XTS-AES-128, 64 bytes for XTS-AES-256.
+// newCipher must be with 128-bit block size, such as aes, twofish.
+func NewXTS(key []byte, newCipher func(key []byte) (cipher.Block, error)) (XTSCrypt, error) {
+	c, err := xts.NewCipher(newCipher, key)
+	if err != nil {
+		return nil, fmt.Errorf("aesext: create cipher: %w", err)
+	}
+	return &xtsCrypt{c}, nil
+}
+
+type xtsCrypt struct {
+	c *xts.Cipher
+}
+
+const xtsBlockSize = 16
+
+// Encrypt encrypt
+func (sf *xtsCrypt) Encrypt(sectorNum uint64, plainText []byte) ([]byte, error) {
+	if len(plainText)%xtsBlockSize != 0 {
+		return nil, ErrInputNotMultipleBlocks
+	}
+	cipherText := make([]byte, len(plainText))
+	sf.c.Encrypt(cipherText, plainText, sectorNum)
+	return cipherText, nil
+}
+
+// Decrypt decrypt
+func (sf *xtsCrypt) Decrypt(sectorNum uint64, cipherText []byte) ([]byte, error) {
+	if len(cipherText)%xtsBlockSize != 0 {
+		return nil, ErrInputNotMultipleBlocks
+	}
+	plainText := make([]byte, len(cipherText))
+	sf.c.Decrypt(plainText, cipherText, sectorNum)
+	return plainText, nil
+}
@@ -0,0 +1,76 @@
+package aesext
+
+import (
+	"crypto/aes"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXTS(t *testing.T) {
+	key := sha512.Sum512([]byte("secret_key"))
+	plainText := []byte("sector data, it must be multiple of 16 bytes.....")[:48]
+
+	t.Run("ieee 1619 vector", func(t *testing.T) {
+		// IEEE 1619-2007 Vector 1
+		bc, err := NewXTS(make([]byte, 32), aes.NewCipher)
+		require.NoError(t, err)
+
+		cipherText, err := bc.Encrypt(0, make([]byte, 32))
+		require.NoError(t, err)
+		assert.Equal(t, "917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e", hex.EncodeToString(cipherText))
+	})
+
+	t.Run("sector tweak", func(t *testing.T) {
+		for _, keySize := range []int{32, 64} {
+			bc, err := NewXTS(key[:keySize], aes.NewCipher)
+			require.NoError(t, err)
+
+			cipherText1, err := bc.Encrypt(1, plainText)
+			require.NoError(t, err)
+			assert.Equal(t, len(plainText), len(cipherText1))
+			cipherText2, err := bc.Encrypt(2, plainText)
+			require.NoError(t, err)
+			assert.NotEqual(t, cipherText1, cipherText2)
+
+			got, err := bc.Decrypt(1, cipherText1)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+			got, err = bc.Decrypt(2, cipherText2)
+			require.NoError(t, err)
+			assert.Equal(t, plainText, got)
+
+			got, err = bc.Decrypt(2, cipherText1)
+			require.NoError(t, err)
+			assert.NotEqual(t, plainText, got)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		bc, err := NewXTS(key[:32], aes.NewCipher)
+		require.NoError(t, err)
+		cipherText, err := bc.Encrypt(0, nil)
+		require.NoError(t, err)
+		got, err := bc.Decrypt(0, cipherText)
+		require.NoError(t, err)
+		assert.NotNil(t, got)
+		assert.Empty(t, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewXTS(key[:16], aes.NewCipher)
+		require.Error(t, err)
+		_, err = NewXTS(key[:32], mockErrorNewCipher)
+		require.Error(t, err)
+
+		bc, err := NewXTS(key[:32], aes.NewCipher)
+		require.NoError(t, err)
+		_, err = bc.Encrypt(0, plainText[:17])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+		_, err = bc.Decrypt(0, plainText[:15])
+		require.Equal(t, ErrInputNotMultipleBlocks, err)
+	})
+}